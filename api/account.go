@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+func (a *API) accountPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.accountJSON(w, r)
+		return
+	}
+
+	acc, err := a.s.Account(r.PathValue("key"))
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, "account not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to get account", slog.Any("err", err))
+		http.Error(w, "failed to get account", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("Hx-Request") == "true" {
+		w.Header().Set("HX-Push-Url", r.URL.EscapedPath())
+		if err := templates.Account(acc).Render(r.Context(), w); err != nil {
+			slog.Error("failed to render Account", slog.Any("err", err))
+		}
+		return
+	}
+
+	push(w)
+	if err := templates.AccountPage(acc).Render(r.Context(), w); err != nil {
+		slog.Error("failed to render AccountPage", slog.Any("err", err))
+	}
+}
+
+func (a *API) accountJSON(w http.ResponseWriter, r *http.Request) {
+	acc, err := a.s.Account(r.PathValue("key"))
+	if errors.Is(err, model.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("account not found"))
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to get account", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get account"))
+		return
+	}
+
+	if acc.Transactions == nil {
+		acc.Transactions = map[string]uint64{}
+	}
+	if acc.RecentTransactions == nil {
+		acc.RecentTransactions = []model.Event{}
+	}
+	writeJSON(w, http.StatusOK, acc)
+}
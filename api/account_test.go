@@ -0,0 +1,83 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountJSON(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := &MockStore{account: model.AccountInfo{Account: model.Account{
+		Key:          "04ff",
+		Whitelisted:  true,
+		FirstSeen:    ts,
+		LastSeen:     ts.Add(time.Hour),
+		Transactions: map[string]uint64{"run": 2, "deploy": 1},
+	}}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/account/04ff")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{
+		"key": "04ff",
+		"whitelisted": true,
+		"first_seen": "2024-03-01T12:00:00Z",
+		"last_seen": "2024-03-01T13:00:00Z",
+		"transactions": {"run": 2, "deploy": 1},
+		"recent_transactions": []
+	}`, resp.Body.String())
+
+	// Only whitelisted account has empty counts instead of null.
+	s.account = model.AccountInfo{Account: model.Account{Key: "04ff", Whitelisted: true}}
+	resp = get(t, a, "/account/04ff?format=json")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, map[string]any{}, body["transactions"])
+
+	s.accountErr = fmt.Errorf("account 04aa: %w", model.ErrNotFound)
+	assertErrorResponse(t, get(t, a, "/api/v2/account/04aa"), http.StatusNotFound)
+
+	s.accountErr = errors.New("db down")
+	assertErrorResponse(t, get(t, a, "/api/v2/account/04ff"), http.StatusInternalServerError)
+}
+
+func TestAccountHTML(t *testing.T) {
+	s := &MockStore{account: model.AccountInfo{
+		Account:            model.Account{Key: "04ff", Transactions: map[string]uint64{"proof": 3, "deploy": 1}},
+		RecentTransactions: []model.Event{{State: model.StateProving, TxID: "tx1", Kind: "proof"}},
+	}}
+	a := newTestAPI(t, s)
+
+	r := httptest.NewRequest(http.MethodGet, "/account/04ff", nil)
+	r.Header.Set("Hx-Request", "true")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/account/04ff", w.Header().Get("HX-Push-Url"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<div class="tx-id-block-value">04ff</div>`)
+	assert.Contains(t, body, `<div class="info-stat-value">No</div>`)
+	assert.Contains(t, body, `hx-get="/transactions?author=04ff&amp;kind=deploy"`)
+	assert.Contains(t, body, `hx-get="/transactions?author=04ff&amp;kind=proof"`)
+	assert.Contains(t, body, `hx-get="/transactions?author=04ff"`)
+	assert.Contains(t, body, `<div id="tx1" class="tr"`)
+
+	resp := get(t, a, "/account/04ff")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "<!doctype html>")
+
+	s.accountErr = model.ErrNotFound
+	resp = get(t, a, "/account/04aa")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
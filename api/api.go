@@ -3,6 +3,8 @@ package api
 
 import (
 	"embed"
+	"errors"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -11,30 +13,80 @@ import (
 	"time"
 
 	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
 	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 //go:embed all:assets
 var assets embed.FS
 
 type Store interface {
-	Search(filter string) ([]model.Event, error)
-	CachedStats(model.StatsRange) model.CombinedStats
+	Search(model.TxFilter) (model.SearchResult, error)
+	CachedStats(model.StatsRange) (model.CombinedStats, error)
+	CachedStatsSeries(model.StatsRange) ([]model.Stats, error)
+	StatsSeries(model.StatsRange, model.StatsBucket) ([]model.Stats, error)
+	HourlyStats(since, until time.Time) ([]model.HourlyStats, error)
+	CachedLeaderboard(model.StatsRange) []model.NodeRank
 	Events() <-chan model.Event
 	TxInfo(id string) (model.TxInfo, error)
+	Transactions(model.TxFilter, model.Page) (model.TxPage, error)
+	Program(hash string) (model.ProgramInfo, error)
+	Account(key string) (model.AccountInfo, error)
+	Payload(tx string, offset, length int) (model.Payload, []byte, error)
 }
 
+const (
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultClientRetry       = 3 * time.Second
+	DefaultMinPrefixLength   = 4
+)
+
 type API struct {
 	r *http.ServeMux
 	s Store
 	b *Broadcaster
+
+	heartbeatInterval time.Duration
+	clientRetry       time.Duration
+	minPrefixLen      int
+	checks            []readinessCheck
+}
+
+// Option configures optional API parameters.
+type Option func(*API)
+
+// WithHeartbeatInterval sets how often SSE heartbeat comments are sent to idle clients.
+// Connections are closed if heartbeat can't be written within the interval.
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(a *API) { a.heartbeatInterval = d }
+}
+
+// WithMinPrefixLength sets minimum length of search text that is matched as
+// hash prefix. Shorter search text must match hashes exactly.
+func WithMinPrefixLength(n int) Option {
+	return func(a *API) { a.minPrefixLen = n }
+}
+
+// WithClientRetry sets the reconnection delay suggested to SSE clients.
+func WithClientRetry(d time.Duration) Option {
+	return func(a *API) { a.clientRetry = d }
 }
 
-func New(s Store, b *Broadcaster) (*API, error) {
+func New(s Store, b *Broadcaster, opts ...Option) (*API, error) {
 	a := &API{
-		r: http.NewServeMux(),
-		s: s,
-		b: b,
+		r:                 http.NewServeMux(),
+		s:                 s,
+		b:                 b,
+		heartbeatInterval: DefaultHeartbeatInterval,
+		clientRetry:       DefaultClientRetry,
+		minPrefixLen:      DefaultMinPrefixLength,
+		checks:            []readinessCheck{{name: "broadcaster", c: b, critical: true}},
+	}
+
+	for _, opt := range opts {
+		opt(a)
 	}
 
 	assetsFS, err := fs.Sub(assets, "assets")
@@ -42,21 +94,57 @@ func New(s Store, b *Broadcaster) (*API, error) {
 		return nil, err
 	}
 
-	a.r.HandleFunc("GET /", a.index)
-	a.r.HandleFunc("GET /tx/{tx}", a.txPage)
+	a.handle("GET /", http.HandlerFunc(a.index))
+	a.handle("GET /tx/{tx}", http.HandlerFunc(a.txPage))
+	a.handle("GET /transactions", http.HandlerFunc(a.transactions))
+	a.handle("GET /program/{hash}", http.HandlerFunc(a.programPage))
+	a.handle("GET /account/{key}", http.HandlerFunc(a.accountPage))
+	a.handle("GET /leaderboard", http.HandlerFunc(a.leaderboard))
+	a.handle("GET /tx/{tx}/preview", http.HandlerFunc(a.previewPage))
+	a.handle("GET /api/v1/stats", http.HandlerFunc(a.stats))
+	a.handle("GET /api/v1/events", http.HandlerFunc(a.table))
+	a.handle("GET /api/v2/stats", http.HandlerFunc(a.statsJSON))
+	a.handle("GET /api/v2/stats/series", http.HandlerFunc(a.statsSeriesJSON))
+	a.handle("GET /api/v2/stats/hourly", http.HandlerFunc(a.hourlyStatsJSON))
+	a.handle("GET /api/v2/search", http.HandlerFunc(a.searchJSON))
+	a.handle("GET /api/v2/tx/{tx}", http.HandlerFunc(a.txJSON))
+	a.handle("GET /api/v2/transactions", http.HandlerFunc(a.transactionsJSON))
+	a.handle("GET /api/v2/program/{hash}", http.HandlerFunc(a.programJSON))
+	a.handle("GET /api/v2/account/{key}", http.HandlerFunc(a.accountJSON))
+	a.handle("GET /api/v2/leaderboard", http.HandlerFunc(a.leaderboardJSON))
+	a.handle("GET /api/v2/tx/{tx}/payload", http.HandlerFunc(a.payload))
+	a.handle("GET /api/v2/tx/{tx}/preview", http.HandlerFunc(a.previewJSON))
+	a.handle("GET /assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(assetsFS))))
+
+	// Long-lived connections and probes are left out from request duration metrics.
 	a.r.HandleFunc("GET /api/v1/stream", a.stream)
-	a.r.HandleFunc("GET /api/v1/stats", a.stats)
-	a.r.HandleFunc("GET /api/v1/events", a.table)
-	a.r.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(assetsFS))))
+	a.r.HandleFunc("GET /api/v1/ws", a.websocket)
+	a.r.Handle("GET /metrics", promhttp.Handler())
+	a.r.HandleFunc("GET /healthz", a.healthz)
+	a.r.HandleFunc("GET /readyz", a.readyz)
+	a.r.HandleFunc("GET /debug/subscribers", a.subscribers)
 
 	return a, nil
 }
 
+// handle registers handler for the pattern and records its request durations.
+func (a *API) handle(pattern string, h http.Handler) {
+	_, route, _ := strings.Cut(pattern, " ")
+	obs := metrics.HTTPRequestDuration.MustCurryWith(prometheus.Labels{"route": route})
+	a.r.Handle(pattern, promhttp.InstrumentHandlerDuration(obs, h))
+}
+
 func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	a.r.ServeHTTP(w, r)
 }
 
 func (a *API) index(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.eventsJSON(w, r)
+		return
+	}
+
 	if r.Header.Get("Hx-Request") == "true" {
 		w.Header().Set("HX-Push-Url", r.URL.EscapedPath())
 		a.table(w, r)
@@ -70,6 +158,12 @@ func (a *API) index(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *API) txPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.txJSON(w, r)
+		return
+	}
+
 	tx := r.PathValue("tx")
 	txInfo, err := a.s.TxInfo(tx)
 	if err != nil {
@@ -93,20 +187,50 @@ func (a *API) txPage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *API) stats(w http.ResponseWriter, r *http.Request) {
-	sr, err := model.ParseStatsRange(r.URL.Query().Get("range"))
+	sr, err := parseStatsRange(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if err := templates.Stats(a.s.CachedStats(sr)).Render(r.Context(), w); err != nil {
+	stats, err := a.s.CachedStats(sr)
+	if err != nil {
+		slog.Error("failed to get stats", slog.Any("err", err))
+		http.Error(w, "failed to get stats", http.StatusInternalServerError)
+		return
+	}
+
+	series, err := a.s.CachedStatsSeries(sr)
+	if err != nil {
+		slog.Error("failed to get stats series", slog.Any("err", err))
+		http.Error(w, "failed to get stats series", http.StatusInternalServerError)
+		return
+	}
+
+	if err := templates.Stats(stats, series).Render(r.Context(), w); err != nil {
 		slog.Error("failed to render stats", slog.Any("err", err))
 		return
 	}
 }
 
+// parseStatsRange parses stats range from range query parameter or, if range
+// is missing or custom, from from and to query parameters.
+func parseStatsRange(r *http.Request) (model.StatsRange, error) {
+	q := r.URL.Query()
+	if v := q.Get("range"); v != "" && v != "custom" {
+		return model.ParseStatsRange(v)
+	}
+	return model.ParseStatsTimeRange(q.Get("from"), q.Get("to"))
+}
+
 func (a *API) table(w http.ResponseWriter, r *http.Request) {
-	q := strings.ToLower(r.URL.Query().Get("q"))
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.eventsJSON(w, r)
+		return
+	}
+
+	q := r.URL.Query().Get("q")
 	if q == "" {
 		if err := templates.Table(nil, url.Values{}).Render(r.Context(), w); err != nil {
 			slog.Error("failed to render stats", slog.Any("err", err))
@@ -114,7 +238,13 @@ func (a *API) table(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	events, err := a.s.Search(q)
+	f, err := model.ParseSearchQuery(q, a.minPrefixLen)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := a.s.Search(f)
 	if err != nil {
 		// Let's not return an error to the client but instead continue with empty result set.
 		slog.Error("failed to search events", slog.Any("err", err))
@@ -122,48 +252,89 @@ func (a *API) table(w http.ResponseWriter, r *http.Request) {
 
 	query := url.Values{}
 	query.Set("q", q)
-	if len(events) > 0 {
-		query.Set("since", events[0].Timestamp.Format(time.RFC3339))
+	if len(res.Transactions) > 0 {
+		query.Set("since", res.Transactions[0].Timestamp.Format(time.RFC3339))
 	}
 
-	if err := templates.Table(events, query).Render(r.Context(), w); err != nil {
+	if err := templates.SearchResults(res, query).Render(r.Context(), w); err != nil {
 		slog.Error("failed to render table", slog.Any("err", err))
 		return
 	}
 }
 
 func (a *API) stream(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+	format, err := ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	prefill := true
 	filter := NoFilter
-	q := strings.ToLower(r.URL.Query().Get("q"))
+	q := r.URL.Query().Get("q")
 	since := r.URL.Query().Get("since")
 	if q != "" {
+		f, err := model.ParseSearchQuery(q, a.minPrefixLen)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !f.Streamable() {
+			http.Error(w, errNotStreamable.Error(), http.StatusBadRequest)
+			return
+		}
+
 		t, err := time.Parse(time.RFC3339, since)
 		if err != nil {
 			slog.Error("failed to parse 'since' time, using 0 time", slog.Any("err", err))
 		}
-		filter = SearchFilter(q, t)
+		filter = SearchFilter(f, t)
 		prefill = false
 	}
 
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
 	slog.Info("client connected", slog.String("remote_addr", r.RemoteAddr))
-	ch, unsubscribe := a.b.Subscribe(filter, prefill)
+	var ch <-chan []byte
+	var unsubscribe func()
+	if lastEventID, ok := parseEventID(r.Header.Get("Last-Event-ID")); ok {
+		ch, unsubscribe = a.b.Resume(filter, format, prefill, lastEventID)
+	} else {
+		ch, unsubscribe = a.b.Subscribe(filter, format, prefill)
+	}
 	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	if err := a.writeSSE(rc, w, []byte(fmt.Sprintf("retry: %d\n\n", a.clientRetry.Milliseconds()))); err != nil {
+		slog.Error("failed to write to client, closing connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(a.heartbeatInterval)
+	defer heartbeat.Stop()
+
 	for {
 		select {
 		case <-r.Context().Done(): // Client disconnected
 			slog.Info("client disconnected", slog.String("remote_addr", r.RemoteAddr))
 			return
-		case data := <-ch:
-			if _, err := w.Write(data); err != nil {
+		case data, ok := <-ch:
+			if !ok {
+				slog.Info("subscription closed by broadcaster, closing connection", slog.String("remote_addr", r.RemoteAddr))
+				return
+			}
+			if err := a.writeSSE(rc, w, data); err != nil {
 				slog.Error("failed to write to client, closing connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
 				return
 			}
-			w.(http.Flusher).Flush()
+			heartbeat.Reset(a.heartbeatInterval)
+		case <-heartbeat.C:
+			if err := a.writeSSE(rc, w, []byte(": heartbeat\n\n")); err != nil {
+				slog.Info("failed to send heartbeat to client, closing connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
+				return
+			}
 		case <-a.b.done:
 			slog.Info("broadcaster stopped, closing connection", slog.String("remote_addr", r.RemoteAddr))
 			return
@@ -171,12 +342,27 @@ func (a *API) stream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func SearchFilter(f string, since time.Time) Filter {
+// writeSSE writes data to the client and flushes it. Write fails if it can't be
+// completed within heartbeat interval.
+func (a *API) writeSSE(rc *http.ResponseController, w http.ResponseWriter, data []byte) error {
+	err := rc.SetWriteDeadline(time.Now().Add(a.heartbeatInterval))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// errNotStreamable is returned for search filters live events can't match.
+var errNotStreamable = errors.New("live events are runs, only kind run can be streamed")
+
+// SearchFilter returns filter matching events after since that match the search filter.
+func SearchFilter(f model.TxFilter, since time.Time) Filter {
 	return func(e model.Event) bool {
-		return e.Timestamp.After(since) &&
-			(strings.Contains(e.ProverID, f) ||
-				strings.Contains(e.TxID, f) ||
-				strings.Contains(e.Tag, f))
+		return e.Timestamp.After(since) && f.Match(e)
 	}
 }
 
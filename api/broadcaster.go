@@ -3,32 +3,91 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
 	"github.com/gevulotnetwork/devnet-explorer/model"
 )
 
-const BufferSize = 100
+const (
+	BufferSize = 100
+	ReplaySize = 10 * BufferSize
+)
+
+// EventTx is the SSE event type of every event in JSON formatted stream.
+const EventTx = "tx"
+
+// Format defines how events are encoded for subscribers.
+type Format uint8
+
+const (
+	FormatHTML Format = iota
+	FormatJSON
+	FormatWebSocket
+
+	numFormats
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatHTML:
+		return "html"
+	case FormatJSON:
+		return "json"
+	case FormatWebSocket:
+		return "websocket"
+	default:
+		return ""
+	}
+}
+
+// MarshalText encodes the format by its name.
+func (f Format) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// ParseFormat parses SSE stream format from string, empty string defaults to HTML.
+func ParseFormat(f string) (Format, error) {
+	switch strings.ToLower(f) {
+	case "", "html":
+		return FormatHTML, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return 0, fmt.Errorf("invalid format: %q", f)
+	}
+}
+
+// encodedEvent holds an event rendered once for every supported format.
+type encodedEvent [numFormats][]byte
 
 type Broadcaster struct {
 	s    EventStream
 	head *eventBuffer
+	log  *replayLog
+
+	// lastEventID is id of the latest broadcasted event. It is modified only
+	// by broadcast while holding clientsMu.
+	lastEventID uint64
 
 	clientsMu sync.Mutex
-	clients   map[uint64]member
+	clients   map[uint64]*subscriber
 	nextID    uint64
 
-	retryTimeout time.Duration
-	done         chan struct{}
-}
-type member struct {
-	ch     chan<- []byte
-	filter Filter
+	policy    SlowClientPolicy
+	queueSize int
+	running   atomic.Bool
+	done      chan struct{}
 }
 
 type Filter func(model.Event) bool
@@ -37,40 +96,120 @@ type EventStream interface {
 	Events() <-chan model.Event
 }
 
-func NewBroadcaster(s EventStream, retryTimeout time.Duration) *Broadcaster {
+// NewBroadcaster creates broadcaster where each subscriber can have up to queueSize
+// events waiting to be sent before policy is applied.
+func NewBroadcaster(s EventStream, policy SlowClientPolicy, queueSize int) *Broadcaster {
 	return &Broadcaster{
-		s:            s,
-		clients:      make(map[uint64]member),
-		retryTimeout: retryTimeout,
-		head:         newEventBuffer(BufferSize),
-		done:         make(chan struct{}),
+		s:         s,
+		clients:   make(map[uint64]*subscriber),
+		policy:    policy,
+		queueSize: queueSize,
+		head:      newEventBuffer(BufferSize),
+		log:       newReplayLog(ReplaySize),
+		// Event IDs start from current time so that IDs stay increasing over restarts
+		// and IDs received from previous process are never mistaken as recent ones.
+		lastEventID: uint64(time.Now().UnixMicro()),
+		done:        make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes to events matching the filter. If prefill is true,
+// events in the prefill buffer are sent before live events. Returned channel is
+// closed after unsubscribe or if broadcaster disconnects the slow subscriber.
+func (b *Broadcaster) Subscribe(f Filter, format Format, prefill bool) (data <-chan []byte, unsubscribe func()) {
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+
+	s, unsubscribe := b.subscribe(f, format)
+	if prefill {
+		b.prefill(s)
 	}
+	return s.ch, unsubscribe
 }
 
-func (b *Broadcaster) Subscribe(f Filter, prefill bool) (data <-chan []byte, unsubscribe func()) {
+// Resume subscribes like Subscribe but first sends events matching the filter that
+// were broadcasted after lastEventID. If the events after lastEventID are no longer
+// held in replay log, it falls back to Subscribe's prefill behavior.
+func (b *Broadcaster) Resume(f Filter, format Format, prefill bool, lastEventID uint64) (data <-chan []byte, unsubscribe func()) {
 	b.clientsMu.Lock()
 	defer b.clientsMu.Unlock()
 
+	s, unsubscribe := b.subscribe(f, format)
+	entries, ok := b.log.since(lastEventID)
+	if !ok {
+		slog.Info("last event id not found from replay log, falling back to prefill", slog.Uint64("last_event_id", lastEventID))
+		if prefill {
+			b.prefill(s)
+		}
+		return s.ch, unsubscribe
+	}
+
+	missed := make([][]byte, 0, len(entries)+1)
+	for _, e := range entries {
+		if f(e.event) {
+			missed = append(missed, e.data[format])
+		}
+	}
+	s.sendAll(append(missed, idFrame(b.lastEventID)))
+	return s.ch, unsubscribe
+}
+
+// subscribe adds new subscriber to clients, clientsMu must be held by the caller.
+func (b *Broadcaster) subscribe(f Filter, format Format) (*subscriber, func()) {
 	id := b.nextID
-	ch := make(chan []byte, BufferSize+5) // +5 to avoid unnecessary blocking on broadcast
-	b.clients[id] = member{ch: ch, filter: f}
+	s := newSubscriber(id, f, format, b.policy, b.queueSize)
+	b.clients[id] = s
 	b.nextID++
+	metrics.Subscribers.WithLabelValues(format.String()).Inc()
 	slog.Info("client subscribed", slog.Uint64("id", id))
 
-	if prefill {
-		b.head.writeAllToCh(ch)
+	return s, func() {
+		b.clientsMu.Lock()
+		if _, ok := b.clients[id]; ok {
+			delete(b.clients, id)
+			metrics.Subscribers.WithLabelValues(format.String()).Dec()
+		}
+		b.clientsMu.Unlock()
+
+		s.close()
+		<-s.exited
+		slog.Info("client unsubscribed", slog.Uint64("id", id), slog.Uint64("dropped", s.dropped.Load()))
 	}
+}
 
-	return ch, func() {
-		slog.Info("client unsubscribed", slog.Uint64("id", id))
-		b.clientsMu.Lock()
-		defer b.clientsMu.Unlock()
-		delete(b.clients, id)
-		close(ch)
+// prefill sends prefill buffer to subscriber, clientsMu must be held by the caller.
+func (b *Broadcaster) prefill(s *subscriber) {
+	data := b.head.all(s.format)
+	if len(data) > 0 && s.format != FormatWebSocket {
+		// Buffered events are not in id order, so let client know id of the latest one.
+		data = append(data, idFrame(b.lastEventID))
+	}
+	s.sendAll(data)
+}
+
+// Subscribers returns stats of currently connected subscribers.
+func (b *Broadcaster) Subscribers() []SubscriberStats {
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+
+	stats := make([]SubscriberStats, 0, len(b.clients))
+	for _, s := range b.clients {
+		stats = append(stats, s.stats())
 	}
+	return stats
+}
+
+// Recent returns events currently held in the prefill buffer, newest first.
+func (b *Broadcaster) Recent() []model.Event {
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+	return b.head.events()
 }
 
 func (b *Broadcaster) Run() error {
+	b.running.Store(true)
+	defer b.running.Store(false)
+
 	for {
 		select {
 		case e, ok := <-b.s.Events():
@@ -87,56 +226,113 @@ func (b *Broadcaster) Run() error {
 
 func (b *Broadcaster) broadcast(e model.Event) {
 	slog.Debug("new tx event received")
-	buf := &bytes.Buffer{}
-	if err := writeEvent(buf, e); err != nil {
-		slog.Error("failed write event into buffer", slog.Any("error", err))
+	// broadcast is the only writer of lastEventID, so it can be read without lock.
+	eventID := b.lastEventID + 1
+	data, err := encodeEvent(eventID, e)
+	if err != nil {
+		slog.Error("failed to encode event", slog.Any("error", err))
 		return
 	}
-	data := buf.Bytes()
 
 	b.clientsMu.Lock()
 	defer b.clientsMu.Unlock()
 
+	b.lastEventID = eventID
+	b.log.add(eventID, e, data)
 	b.head.add(e, data)
-	blocked := make([]uint64, 0, len(b.clients))
+	metrics.EventsBroadcast.Inc()
 	for id, c := range b.clients {
-		if c.filter(e) {
-			select {
-			case c.ch <- data:
-				slog.Debug("data broadcasted", slog.Uint64("id", id))
-			default:
-				slog.Info("client blocked, adding to retry block", slog.Uint64("id", id))
-				blocked = append(blocked, id)
-			}
+		if !c.filter(e) {
+			continue
 		}
-	}
 
-	for _, id := range blocked {
-		select {
-		case b.clients[id].ch <- data:
-			slog.Debug("data broadcasted", slog.Uint64("id", id))
-		case <-time.After(b.retryTimeout):
-			slog.Info("client blocked after retry, skipping", slog.Uint64("id", id))
-			blocked = append(blocked, id)
+		if !c.send(data[c.format]) {
+			slog.Info("client too slow, disconnecting", slog.Uint64("id", id), slog.Uint64("dropped", c.dropped.Load()))
+			delete(b.clients, id)
+			c.close()
+			metrics.Subscribers.WithLabelValues(c.format.String()).Dec()
+			metrics.SubscribersDisconnected.Inc()
 		}
 	}
 }
 
+// Ready returns an error if broadcaster is not running.
+func (b *Broadcaster) Ready() error {
+	if !b.running.Load() {
+		return errors.New("broadcaster not running")
+	}
+	return nil
+}
+
 func (b *Broadcaster) Stop() error {
 	close(b.done)
 	return nil
 }
 
-func writeEvent(w io.Writer, e model.Event) error {
+func encodeEvent(id uint64, e model.Event) (data encodedEvent, err error) {
+	buf := &bytes.Buffer{}
+	if err := writeEvent(buf, id, e); err != nil {
+		return data, err
+	}
+	data[FormatHTML] = buf.Bytes()
+
+	buf = &bytes.Buffer{}
+	if err := writeJSONEvent(buf, id, e); err != nil {
+		return data, err
+	}
+	data[FormatJSON] = buf.Bytes()
+
+	if data[FormatWebSocket], err = json.Marshal(wsEvent{Type: wsTypeEvent, ID: id, Event: &e}); err != nil {
+		return data, fmt.Errorf("failed to marshal json: %w", err)
+	}
+	return data, nil
+}
+
+func writeEvent(w io.Writer, id uint64, e model.Event) error {
 	eType := e.TxID
 	if e.State == model.StateSubmitted {
 		eType = templates.EventTXRow
 	}
 
-	fmt.Fprintf(w, "event: %s\ndata: ", eType)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: ", id, eType)
 	if err := templates.Row(e).Render(context.Background(), w); err != nil {
 		return fmt.Errorf("failed render html: %w", err)
 	}
 	fmt.Fprint(w, "\n\n")
 	return nil
 }
+
+func writeJSONEvent(w io.Writer, id uint64, e model.Event) error {
+	data, err := json.Marshal(&e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %w", err)
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, EventTx, data)
+	return nil
+}
+
+// idFrame returns SSE message that only updates client's last event ID.
+// Clients do not dispatch messages without data.
+func idFrame(id uint64) []byte {
+	return []byte(fmt.Sprintf("id: %d\n\n", id))
+}
+
+// resyncFrame returns message telling client that events were dropped and it
+// should reload its state.
+func resyncFrame(format Format) []byte {
+	switch format {
+	case FormatWebSocket:
+		return []byte(`{"type":"` + wsTypeResync + `"}`)
+	case FormatJSON:
+		return []byte("event: " + templates.EventResync + "\ndata: {}\n\n")
+	default:
+		return []byte("event: " + templates.EventResync + "\ndata: " + templates.EventResync + "\n\n")
+	}
+}
+
+// parseEventID parses SSE Last-Event-ID header value.
+func parseEventID(s string) (uint64, bool) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	return id, err == nil
+}
@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,17 +14,15 @@ import (
 )
 
 func TestBroadcasterOneClient(t *testing.T) {
-	s := &MockStore{
-		events: make(chan model.Event, 1000),
-	}
+	s := make(eventStream, 1000)
 
-	b := api.NewBroadcaster(s, time.Millisecond*10)
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
 
 	eg := &multierror.Group{}
 	eg.Go(b.Run)
 
-	ch, unsubscribe := b.Subscribe(api.NoFilter, true)
-	s.events <- model.Event{State: model.StateProving}
+	ch, unsubscribe := b.Subscribe(api.NoFilter, api.FormatHTML, true)
+	s <- model.Event{State: model.StateProving}
 	select {
 	case <-ch:
 	case <-time.After(time.Second):
@@ -42,23 +41,21 @@ func TestBroadcasterOneClient(t *testing.T) {
 }
 
 func TestBroadcasterBuffer(t *testing.T) {
-	s := &MockStore{
-		events: make(chan model.Event, 1000),
-	}
+	s := make(eventStream, 1000)
 
-	b := api.NewBroadcaster(s, time.Millisecond*10)
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
 	eg := &multierror.Group{}
 	eg.Go(b.Run)
 
 	const numOfEvents = api.BufferSize + 2
 	for i := 0; i < numOfEvents; i++ {
-		s.events <- model.Event{TxID: fmt.Sprint(i), State: model.StateProving}
+		s <- model.Event{TxID: fmt.Sprint(i), State: model.StateProving}
 	}
 
 	// Give server some time to buffer events.
 	time.Sleep(time.Second)
 
-	ch, unsubscribe := b.Subscribe(api.NoFilter, true)
+	ch, unsubscribe := b.Subscribe(api.NoFilter, api.FormatHTML, true)
 	for i := 0; i < api.BufferSize; i++ {
 		select {
 		case <-ch:
@@ -79,11 +76,9 @@ func TestBroadcasterBuffer(t *testing.T) {
 }
 
 func TestBroadcasterStuckClient(t *testing.T) {
-	s := &MockStore{
-		events: make(chan model.Event, 1000),
-	}
+	s := make(eventStream, 1000)
 
-	b := api.NewBroadcaster(s, time.Millisecond*10)
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
 
 	eg := &multierror.Group{}
 	eg.Go(b.Run)
@@ -92,7 +87,7 @@ func TestBroadcasterStuckClient(t *testing.T) {
 	done := make(chan struct{})
 
 	// Simulate stuck client by not reading from the channel.
-	_, unsubscribe := b.Subscribe(api.NoFilter, true)
+	_, unsubscribe := b.Subscribe(api.NoFilter, api.FormatHTML, true)
 	defer unsubscribe()
 
 	ready := make(chan struct{})
@@ -101,7 +96,7 @@ func TestBroadcasterStuckClient(t *testing.T) {
 	go func() {
 		defer close(done)
 		counter := 0
-		ch, unsubscribe := b.Subscribe(api.NoFilter, true)
+		ch, unsubscribe := b.Subscribe(api.NoFilter, api.FormatHTML, true)
 		defer unsubscribe()
 		close(ready)
 		for {
@@ -120,7 +115,7 @@ func TestBroadcasterStuckClient(t *testing.T) {
 
 	<-ready
 	for i := 0; i < numOfEvents; i++ {
-		s.events <- model.Event{State: model.StateProving}
+		s <- model.Event{State: model.StateProving}
 	}
 
 	<-done
@@ -129,12 +124,10 @@ func TestBroadcasterStuckClient(t *testing.T) {
 	require.NoError(t, eg.Wait().ErrorOrNil())
 }
 
-func TestBroadcasterRetry(t *testing.T) {
-	s := &MockStore{
-		events: make(chan model.Event, 1000),
-	}
+func TestBroadcasterSlowClient(t *testing.T) {
+	s := make(eventStream, 1000)
 
-	b := api.NewBroadcaster(s, time.Second)
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
 
 	eg := &multierror.Group{}
 	eg.Go(b.Run)
@@ -143,11 +136,11 @@ func TestBroadcasterRetry(t *testing.T) {
 	done := make(chan struct{})
 
 	ready := make(chan struct{})
-	// Receive all events with bit of sleep to trigger retry.
+	// Receive all events with bit of sleep to simulate slow client.
 	go func() {
 		defer close(done)
 		counter := 0
-		ch, unsubscribe := b.Subscribe(api.NoFilter, true)
+		ch, unsubscribe := b.Subscribe(api.NoFilter, api.FormatHTML, true)
 		defer unsubscribe()
 		close(ready)
 		for {
@@ -157,7 +150,7 @@ func TestBroadcasterRetry(t *testing.T) {
 				if counter == numOfEvents {
 					return
 				}
-				// Sleep to trigger retry.
+				// Sleep to fill up the send queue.
 				time.Sleep(5 * time.Millisecond)
 			case <-time.After(time.Second * 5):
 				t.Error("did not receive event")
@@ -168,7 +161,7 @@ func TestBroadcasterRetry(t *testing.T) {
 
 	<-ready
 	for i := 0; i < numOfEvents; i++ {
-		s.events <- model.Event{State: model.StateProving}
+		s <- model.Event{State: model.StateProving}
 	}
 
 	<-done
@@ -177,15 +170,197 @@ func TestBroadcasterRetry(t *testing.T) {
 	require.NoError(t, eg.Wait().ErrorOrNil())
 }
 
-type MockStore struct {
-	stats        model.CombinedStats
-	searchResult []model.Event
-	searchErr    error
-	events       chan model.Event
-	txInfo       model.TxInfo
+func TestBroadcasterSlowClientPolicy(t *testing.T) {
+	const (
+		queueSize   = 5
+		numOfEvents = 20
+	)
+
+	tests := []struct {
+		policy api.SlowClientPolicy
+		check  func(t *testing.T, ch <-chan []byte)
+	}{
+		{
+			policy: api.PolicyDropOldest,
+			check: func(t *testing.T, ch <-chan []byte) {
+				var last string
+				for data := range receiveAll(ch) {
+					last = data
+				}
+				assert.Contains(t, last, fmt.Sprintf(`<div id="%d"`, numOfEvents-1))
+			},
+		},
+		{
+			policy: api.PolicyDisconnect,
+			check: func(t *testing.T, ch <-chan []byte) {
+				for range receiveAll(ch) {
+				}
+				_, ok := <-ch
+				assert.False(t, ok, "channel not closed")
+			},
+		},
+		{
+			policy: api.PolicyResync,
+			check: func(t *testing.T, ch <-chan []byte) {
+				resync := false
+				for data := range receiveAll(ch) {
+					resync = resync || data == "event: resync\ndata: resync\n\n"
+				}
+				assert.True(t, resync, "resync event not received")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			s := make(eventStream, 1000)
+
+			b := api.NewBroadcaster(s, tt.policy, queueSize)
+			eg := &multierror.Group{}
+			eg.Go(b.Run)
+
+			// Simulate stuck client by not reading from the channel until all events are sent.
+			ch, unsubscribe := b.Subscribe(api.NoFilter, api.FormatHTML, false)
+			defer unsubscribe()
+
+			for i := 0; i < numOfEvents; i++ {
+				s <- model.Event{TxID: fmt.Sprint(i), State: model.StateProving}
+			}
+
+			require.Eventually(t, func() bool {
+				subscribers := b.Subscribers()
+				return len(subscribers) == 0 || subscribers[0].Dropped > 0
+			}, time.Second, time.Millisecond*10)
+
+			tt.check(t, ch)
+
+			assert.NoError(t, b.Stop())
+			require.NoError(t, eg.Wait().ErrorOrNil())
+		})
+	}
+}
+
+func TestBroadcasterFormats(t *testing.T) {
+	s := make(eventStream, 1000)
+
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+
+	s <- model.Event{TxID: "1", State: model.StateSubmitted}
+
+	// Give broadcaster some time to buffer the event.
+	time.Sleep(100 * time.Millisecond)
+
+	htmlCh, unsubscribeHTML := b.Subscribe(api.NoFilter, api.FormatHTML, true)
+	defer unsubscribeHTML()
+	jsonCh, unsubscribeJSON := b.Subscribe(api.NoFilter, api.FormatJSON, true)
+	defer unsubscribeJSON()
+
+	s <- model.Event{TxID: "2", State: model.StateProving}
+
+	for _, txID := range []string{"1", "2"} {
+		data := receive(t, htmlCh)
+		assert.Contains(t, data, `<div id="`+txID+`"`)
+
+		data = receive(t, jsonCh)
+		assert.Contains(t, data, "\nevent: tx\ndata: {", data)
+		assert.Contains(t, data, `"tx_id":"`+txID+`"`)
+	}
+
+	assert.NoError(t, b.Stop())
+	require.NoError(t, eg.Wait().ErrorOrNil())
+}
+
+func TestBroadcasterResume(t *testing.T) {
+	s := make(eventStream, 1000)
+
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+
+	ch, unsubscribe := b.Subscribe(api.NoFilter, api.FormatJSON, false)
+	ids := make([]uint64, 0, 3)
+	for i := 0; i < 3; i++ {
+		s <- model.Event{TxID: fmt.Sprint(i), State: model.StateSubmitted}
+		ids = append(ids, eventID(t, receive(t, ch)))
+	}
+	unsubscribe()
+
+	assert.Equal(t, ids[0]+1, ids[1])
+	assert.Equal(t, ids[1]+1, ids[2])
+
+	// Only events after given id are replayed.
+	ch, unsubscribe = b.Resume(api.NoFilter, api.FormatJSON, true, ids[0])
+	for _, id := range ids[1:] {
+		assert.Equal(t, id, eventID(t, receive(t, ch)))
+	}
+	assert.Equal(t, fmt.Sprintf("id: %d\n\n", ids[2]), string(<-ch))
+	unsubscribe()
+
+	// Replayed events are filtered.
+	ch, unsubscribe = b.Resume(func(e model.Event) bool { return e.TxID == "2" }, api.FormatJSON, true, ids[0])
+	assert.Equal(t, ids[2], eventID(t, receive(t, ch)))
+	unsubscribe()
+
+	// Unknown id falls back to prefill.
+	ch, unsubscribe = b.Resume(api.NoFilter, api.FormatJSON, true, ids[0]-100)
+	for range ids {
+		receive(t, ch)
+	}
+	unsubscribe()
+
+	assert.NoError(t, b.Stop())
+	require.NoError(t, eg.Wait().ErrorOrNil())
+}
+
+// receive returns next event from ch skipping messages that contain only event id.
+func receive(t *testing.T, ch <-chan []byte) string {
+	t.Helper()
+	for {
+		select {
+		case data := <-ch:
+			if !strings.Contains(string(data), "\ndata: ") {
+				continue
+			}
+			return string(data)
+		case <-time.After(time.Second):
+			t.Fatal("did not receive event")
+			return ""
+		}
+	}
+}
+
+// receiveAll returns channel yielding events from ch until ch is closed or there
+// are no more events.
+func receiveAll(ch <-chan []byte) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case data, ok := <-ch:
+				if !ok {
+					return
+				}
+				out <- string(data)
+			case <-time.After(100 * time.Millisecond):
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func eventID(t *testing.T, data string) uint64 {
+	t.Helper()
+	var id uint64
+	_, err := fmt.Sscanf(data, "id: %d\n", &id)
+	require.NoError(t, err)
+	return id
 }
 
-func (m *MockStore) TxInfo(string) (model.TxInfo, error)              { return m.txInfo, nil }
-func (m *MockStore) CachedStats(model.StatsRange) model.CombinedStats { return m.stats }
-func (m *MockStore) Events() <-chan model.Event                       { return m.events }
-func (m *MockStore) Search(string) ([]model.Event, error)             { return m.searchResult, m.searchErr }
+// eventStream feeds events to the broadcaster under test.
+type eventStream chan model.Event
+
+func (s eventStream) Events() <-chan model.Event { return s }
@@ -14,8 +14,8 @@ type eventBuffer struct {
 }
 
 type eventData struct {
-	txID string
-	data []byte
+	event model.Event
+	data  encodedEvent
 }
 
 type header struct {
@@ -30,12 +30,12 @@ func newEventBuffer(size uint) *eventBuffer {
 	}
 }
 
-func (b *eventBuffer) add(e model.Event, data []byte) {
-	data = bytes.Replace(data, []byte("event: "+e.TxID), []byte("event: "+templates.EventTXRow), 1)
+func (b *eventBuffer) add(e model.Event, data encodedEvent) {
+	data[FormatHTML] = bytes.Replace(data[FormatHTML], []byte("event: "+e.TxID), []byte("event: "+templates.EventTXRow), 1)
 	old, ok := b.headMap[e.TxID]
 	if !ok {
-		delete(b.headMap, b.head[b.headIndex].txID)
-		b.head[b.headIndex] = eventData{txID: e.TxID, data: data}
+		delete(b.headMap, b.head[b.headIndex].event.TxID)
+		b.head[b.headIndex] = eventData{event: e, data: data}
 		b.headMap[e.TxID] = header{state: e.State, index: b.headIndex}
 		b.headIndex = (b.headIndex + 1) % len(b.head)
 		return
@@ -45,15 +45,29 @@ func (b *eventBuffer) add(e model.Event, data []byte) {
 		return
 	}
 
-	b.head[old.index] = eventData{txID: e.TxID, data: data}
+	b.head[old.index] = eventData{event: e, data: data}
 	b.headMap[e.TxID] = header{state: e.State, index: old.index}
 }
 
-func (b *eventBuffer) writeAllToCh(ch chan<- []byte) {
+func (b *eventBuffer) all(format Format) [][]byte {
+	all := make([][]byte, 0, len(b.headMap))
 	for i := 1; i <= len(b.head); i++ {
-		data := b.head[(b.headIndex+i)%len(b.head)].data
+		data := b.head[(b.headIndex+i)%len(b.head)].data[format]
 		if data != nil {
-			ch <- data
+			all = append(all, data)
 		}
 	}
+	return all
+}
+
+// events returns buffered events in newest first order by their first appearance.
+func (b *eventBuffer) events() []model.Event {
+	events := make([]model.Event, 0, len(b.headMap))
+	for i := len(b.head) - 1; i >= 0; i-- {
+		d := b.head[(b.headIndex+i)%len(b.head)]
+		if d.data[FormatHTML] != nil {
+			events = append(events, d.event)
+		}
+	}
+	return events
 }
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Checker is implemented by components that can report whether they are ready.
+type Checker interface {
+	Ready() error
+}
+
+type readinessCheck struct {
+	name     string
+	c        Checker
+	critical bool
+}
+
+// WithReadinessCheck adds component to the /readyz report. If a critical
+// component is not ready, /readyz responds with 503.
+func WithReadinessCheck(name string, c Checker, critical bool) Option {
+	return func(a *API) {
+		a.checks = append(a.checks, readinessCheck{name: name, c: c, critical: critical})
+	}
+}
+
+const (
+	statusOK   = "ok"
+	statusDown = "down"
+)
+
+type healthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentStatus `json:"components,omitempty"`
+}
+
+type componentStatus struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+// healthz reports only that the process is alive and serving requests.
+func (a *API) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: statusOK})
+}
+
+// readyz reports readiness of every registered component.
+func (a *API) readyz(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{
+		Status:     statusOK,
+		Components: make(map[string]componentStatus, len(a.checks)),
+	}
+
+	code := http.StatusOK
+	for _, check := range a.checks {
+		cs := componentStatus{Status: statusOK, Critical: check.critical}
+		if err := check.c.Ready(); err != nil {
+			cs.Status = statusDown
+			cs.Error = err.Error()
+			if check.critical {
+				resp.Status = statusDown
+				code = http.StatusServiceUnavailable
+			}
+		}
+		resp.Components[check.name] = cs
+	}
+
+	writeJSON(w, code, resp)
+}
+
+// subscribers lists connected stream subscribers with their queued and
+// dropped events, for finding slow clients.
+func (a *API) subscribers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.b.Subscribers())
+}
@@ -0,0 +1,126 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/api"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type checker func() error
+
+func (c checker) Ready() error { return c() }
+
+func TestHealthz(t *testing.T) {
+	a := newTestAPI(t, &MockStore{})
+	resp := get(t, a, "/healthz")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, resp.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	s := &MockStore{events: make(chan model.Event)}
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
+
+	var storeErr error
+	a, err := api.New(s, b,
+		api.WithReadinessCheck("store", checker(func() error { return storeErr }), true),
+		api.WithReadinessCheck("aggregator", checker(func() error { return errors.New("not running") }), false),
+	)
+	require.NoError(t, err)
+
+	type component struct {
+		Status   string `json:"status"`
+		Critical bool   `json:"critical"`
+		Error    string `json:"error"`
+	}
+	readyz := func() (int, string, map[string]component) {
+		resp := get(t, a, "/readyz")
+		var body struct {
+			Status     string               `json:"status"`
+			Components map[string]component `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		return resp.Code, body.Status, body.Components
+	}
+
+	// Broadcaster is not running yet.
+	code, status, components := readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "down", status)
+	assert.Equal(t, component{Status: "down", Critical: true, Error: "broadcaster not running"}, components["broadcaster"])
+
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+	t.Cleanup(func() {
+		require.NoError(t, b.Stop())
+		require.NoError(t, eg.Wait().ErrorOrNil())
+	})
+	require.Eventually(t, func() bool { return b.Ready() == nil }, time.Second, 10*time.Millisecond)
+
+	// Non-critical component being down doesn't make the service unready.
+	code, status, components = readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", status)
+	assert.Equal(t, component{Status: "ok", Critical: true}, components["broadcaster"])
+	assert.Equal(t, component{Status: "ok", Critical: true}, components["store"])
+	assert.Equal(t, component{Status: "down", Critical: false, Error: "not running"}, components["aggregator"])
+
+	storeErr = errors.New("not listening for notifications")
+	code, status, components = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "down", status)
+	assert.Equal(t, component{Status: "down", Critical: true, Error: "not listening for notifications"}, components["store"])
+}
+
+func TestDebugSubscribers(t *testing.T) {
+	s := &MockStore{events: make(chan model.Event, 10)}
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, 1)
+	a, err := api.New(s, b)
+	require.NoError(t, err)
+
+	type subscriber struct {
+		ID      uint64 `json:"id"`
+		Format  string `json:"format"`
+		Queued  int    `json:"queued"`
+		Dropped uint64 `json:"dropped"`
+	}
+	subscribers := func() []subscriber {
+		resp := get(t, a, "/debug/subscribers")
+		require.Equal(t, http.StatusOK, resp.Code)
+		var body []subscriber
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		return body
+	}
+	assert.Empty(t, subscribers())
+
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+	t.Cleanup(func() {
+		require.NoError(t, b.Stop())
+		require.NoError(t, eg.Wait().ErrorOrNil())
+	})
+
+	// Subscriber never reads, so events beyond the queue are dropped.
+	_, unsubscribe := b.Subscribe(api.NoFilter, api.FormatWebSocket, false)
+	defer unsubscribe()
+	for i := 0; i < 5; i++ {
+		s.events <- model.Event{TxID: fmt.Sprint(i), State: model.StateProving}
+	}
+
+	require.Eventually(t, func() bool {
+		subs := subscribers()
+		return len(subs) == 1 && subs[0].Dropped > 0
+	}, time.Second, 10*time.Millisecond)
+	sub := subscribers()[0]
+	assert.Equal(t, "websocket", sub.Format)
+	assert.LessOrEqual(t, sub.Queued, 1)
+}
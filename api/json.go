@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+// DefaultHourlyStatsPeriod is the period of hourly stats returned when since
+// isn't given.
+const DefaultHourlyStatsPeriod = 24 * time.Hour
+
+// errorResponse is the body of every non-2xx response returned by the JSON API.
+type errorResponse struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+func (a *API) statsJSON(w http.ResponseWriter, r *http.Request) {
+	sr, err := parseStatsRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := a.s.CachedStats(sr)
+	if err != nil {
+		slog.Error("failed to get stats", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get stats"))
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (a *API) statsSeriesJSON(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sr, err := parseStatsRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	b := model.BucketDay
+	if v := q.Get("bucket"); v != "" {
+		if b, err = model.ParseStatsBucket(v); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	series, err := a.s.StatsSeries(sr, b)
+	if err != nil {
+		slog.Error("failed to get stats series", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get stats series"))
+		return
+	}
+
+	if series == nil {
+		series = []model.Stats{}
+	}
+	writeJSON(w, http.StatusOK, series)
+}
+
+// hourlyStatsJSON returns hourly stats between since and until, which default
+// to the last 24 hours.
+func (a *API) hourlyStatsJSON(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	until, err := model.ParseTime(q.Get("until"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid until: %w", err))
+		return
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	since, err := model.ParseTime(q.Get("since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since: %w", err))
+		return
+	}
+	if since.IsZero() {
+		since = until.Add(-DefaultHourlyStatsPeriod)
+	}
+
+	if !since.Before(until) {
+		writeError(w, http.StatusBadRequest, errors.New("since must be before until"))
+		return
+	}
+
+	stats, err := a.s.HourlyStats(since, until)
+	if err != nil {
+		slog.Error("failed to get hourly stats", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get hourly stats"))
+		return
+	}
+
+	if stats == nil {
+		stats = []model.HourlyStats{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (a *API) searchJSON(w http.ResponseWriter, r *http.Request) {
+	res, ok := a.search(w, r)
+	if !ok {
+		return
+	}
+
+	if res.Programs == nil {
+		res.Programs = []model.ProgramMatch{}
+	}
+	if res.Accounts == nil {
+		res.Accounts = []model.AccountMatch{}
+	}
+	if res.Transactions == nil {
+		res.Transactions = []model.Event{}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// eventsJSON serves the same events as the HTML table. Without a search query
+// the events currently shown in the live table are returned.
+func (a *API) eventsJSON(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeJSON(w, http.StatusOK, a.b.Recent())
+		return
+	}
+
+	res, ok := a.search(w, r)
+	if !ok {
+		return
+	}
+
+	if res.Transactions == nil {
+		res.Transactions = []model.Event{}
+	}
+	writeJSON(w, http.StatusOK, res.Transactions)
+}
+
+// search searches with query parameter 'q'. If search fails, error is written
+// to the client and false is returned.
+func (a *API) search(w http.ResponseWriter, r *http.Request) (model.SearchResult, bool) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing search query"))
+		return model.SearchResult{}, false
+	}
+
+	f, err := model.ParseSearchQuery(q, a.minPrefixLen)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return model.SearchResult{}, false
+	}
+
+	res, err := a.s.Search(f)
+	if err != nil {
+		slog.Error("failed to search events", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("search failed"))
+		return model.SearchResult{}, false
+	}
+	return res, true
+}
+
+func (a *API) txJSON(w http.ResponseWriter, r *http.Request) {
+	txInfo, err := a.s.TxInfo(r.PathValue("tx"))
+	if errors.Is(err, model.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("tx not found"))
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to get tx info", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get tx info"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, txInfo)
+}
+
+// writeJSON writes v as JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to write json response", slog.Any("err", err))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Status: status, Error: err.Error()})
+}
+
+// wantsJSON reports whether client prefers JSON over HTML, either by
+// setting 'format=json' query parameter or by 'Accept' header.
+func wantsJSON(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return strings.EqualFold(f, "json")
+	}
+
+	var jsonQ, htmlQ float64
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		case "text/html":
+			htmlQ = max(htmlQ, q)
+		}
+	}
+
+	return jsonQ > 0 && jsonQ > htmlQ
+}
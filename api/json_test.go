@@ -0,0 +1,296 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/api"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONStats(t *testing.T) {
+	s := &MockStore{stats: model.CombinedStats{Stats: model.Stats{ProofsGenerated: 42}}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/stats?range=1w")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	var stats model.CombinedStats
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, uint64(42), stats.Stats.ProofsGenerated)
+
+	resp = get(t, a, "/api/v2/stats?range=2x")
+	assertErrorResponse(t, resp, http.StatusBadRequest)
+}
+
+func TestJSONSearch(t *testing.T) {
+	s := &MockStore{searchResult: model.SearchResult{
+		Programs:     []model.ProgramMatch{{Hash: "abc1", Name: "prover"}},
+		Transactions: []model.Event{{State: model.StateProving, TxID: "abc", Timestamp: time.Now()}},
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/search?q=abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var res model.SearchResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	assert.Equal(t, []model.ProgramMatch{{Hash: "abc1", Name: "prover"}}, res.Programs)
+	assert.Equal(t, []model.AccountMatch{}, res.Accounts)
+	require.Len(t, res.Transactions, 1)
+	assert.Equal(t, model.StateProving, res.Transactions[0].State)
+	assert.Equal(t, "abc", res.Transactions[0].TxID)
+	assert.Equal(t, model.TxFilter{Text: "abc"}, s.searchFilter)
+
+	resp = get(t, a, "/api/v2/search?q="+url.QueryEscape("Prover:ABC state:proving"))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, model.TxFilter{Prover: "abc", State: model.StateProving}, s.searchFilter)
+
+	a, err := api.New(s, api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize), api.WithMinPrefixLength(3))
+	require.NoError(t, err)
+	resp = get(t, a, "/api/v2/search?q=abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, model.TxFilter{Text: "abc", TextPrefix: true}, s.searchFilter)
+
+	resp = get(t, a, "/api/v2/search")
+	assertErrorResponse(t, resp, http.StatusBadRequest)
+
+	resp = get(t, a, "/api/v2/search?q=foo:bar")
+	assertErrorResponse(t, resp, http.StatusBadRequest)
+
+	s.searchErr = errors.New("db down")
+	resp = get(t, a, "/api/v2/search?q=abc")
+	assertErrorResponse(t, resp, http.StatusInternalServerError)
+}
+
+func TestJSONTx(t *testing.T) {
+	s := &MockStore{txInfo: model.TxInfo{
+		TxID:  "abc",
+		State: model.StateComplete,
+		Workflow: []model.WorkflowStep{{
+			Sequence: 1,
+			Program:  "p1",
+			Args:     []string{"--mode", "prove"},
+			Inputs:   []model.InputFile{{Name: "in.json", URL: "http://files/in.json", Checksum: "c1"}},
+			Outputs:  []model.OutputFile{{Name: "out.bin", SourceProgram: "p1"}},
+		}},
+		Files: []model.TxFile{{TxID: "abc", Name: "a.bin", URL: "http://files/a.bin", Checksum: "c2"}},
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/tx/abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var info model.TxInfo
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &info))
+	assert.Equal(t, s.txInfo.TxID, info.TxID)
+	assert.Equal(t, model.StateComplete, info.State)
+	assert.Equal(t, s.txInfo.Workflow, info.Workflow)
+	assert.Equal(t, s.txInfo.Files, info.Files)
+
+	s.txInfoErr = fmt.Errorf("tx abc: %w", model.ErrNotFound)
+	resp = get(t, a, "/api/v2/tx/abc")
+	assertErrorResponse(t, resp, http.StatusNotFound)
+
+	s.txInfoErr = errors.New("db down")
+	resp = get(t, a, "/api/v2/tx/abc")
+	assertErrorResponse(t, resp, http.StatusInternalServerError)
+}
+
+func newTestAPI(t *testing.T, s *MockStore) *api.API {
+	a, err := api.New(s, api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize))
+	require.NoError(t, err)
+	return a
+}
+
+func get(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func assertErrorResponse(t *testing.T, resp *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	require.Equal(t, status, resp.Code)
+
+	var body struct {
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, status, body.Status)
+	assert.NotEmpty(t, body.Error)
+}
+
+type MockStore struct {
+	stats        model.CombinedStats
+	statsErr     error
+	series       []model.Stats
+	seriesErr    error
+	hourly       []model.HourlyStats
+	hourlyErr    error
+	searchResult model.SearchResult
+	searchErr    error
+	events       chan model.Event
+	txInfo       model.TxInfo
+	txInfoErr    error
+	txPage       model.TxPage
+	txPageErr    error
+	program      model.ProgramInfo
+	programErr   error
+	account      model.AccountInfo
+	accountErr   error
+	leaderboard  []model.NodeRank
+	payload      model.Payload
+	payloadData  []byte
+	payloadErr   error
+
+	// Last arguments passed to CachedStats, Search, Transactions, StatsSeries
+	// and HourlyStats.
+	statsRange   model.StatsRange
+	searchFilter model.TxFilter
+	txFilter     model.TxFilter
+	page         model.Page
+	bucket       model.StatsBucket
+	since, until time.Time
+}
+
+func (m *MockStore) TxInfo(string) (model.TxInfo, error)                 { return m.txInfo, m.txInfoErr }
+func (m *MockStore) Program(string) (model.ProgramInfo, error)           { return m.program, m.programErr }
+func (m *MockStore) Account(string) (model.AccountInfo, error)           { return m.account, m.accountErr }
+func (m *MockStore) CachedLeaderboard(model.StatsRange) []model.NodeRank { return m.leaderboard }
+func (m *MockStore) Events() <-chan model.Event                          { return m.events }
+
+func (m *MockStore) CachedStats(r model.StatsRange) (model.CombinedStats, error) {
+	m.statsRange = r
+	return m.stats, m.statsErr
+}
+
+func (m *MockStore) CachedStatsSeries(model.StatsRange) ([]model.Stats, error) {
+	return m.series, m.seriesErr
+}
+
+func (m *MockStore) StatsSeries(_ model.StatsRange, b model.StatsBucket) ([]model.Stats, error) {
+	m.bucket = b
+	return m.series, m.seriesErr
+}
+
+func (m *MockStore) HourlyStats(since, until time.Time) ([]model.HourlyStats, error) {
+	m.since, m.until = since, until
+	return m.hourly, m.hourlyErr
+}
+
+func (m *MockStore) Payload(_ string, offset, length int) (model.Payload, []byte, error) {
+	if m.payloadErr != nil {
+		return model.Payload{}, nil, m.payloadErr
+	}
+	// Postgres substring positions are 32-bit integers.
+	if offset+1 > math.MaxInt32 {
+		return model.Payload{}, nil, errors.New("integer out of range")
+	}
+	data := m.payloadData[min(offset, len(m.payloadData)):]
+	if length > 0 && length < len(data) {
+		data = data[:length]
+	}
+	return m.payload, data, nil
+}
+
+func (m *MockStore) Search(f model.TxFilter) (model.SearchResult, error) {
+	m.searchFilter = f
+	return m.searchResult, m.searchErr
+}
+
+func (m *MockStore) Transactions(f model.TxFilter, p model.Page) (model.TxPage, error) {
+	m.txFilter, m.page = f, p
+	return m.txPage, m.txPageErr
+}
+
+func TestContentNegotiation(t *testing.T) {
+	s := &MockStore{
+		events: make(chan model.Event, 10),
+		txInfo: model.TxInfo{TxID: "abc", State: model.StateProving},
+	}
+	a := newTestAPI(t, s)
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		json   bool
+	}{
+		{name: "browser", target: "/tx/abc", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", json: false},
+		{name: "no accept", target: "/tx/abc", json: false},
+		{name: "accept json", target: "/tx/abc", accept: "application/json", json: true},
+		{name: "json preferred", target: "/tx/abc", accept: "text/html;q=0.5, application/json", json: true},
+		{name: "html preferred", target: "/tx/abc", accept: "text/html, application/json;q=0.5", json: false},
+		{name: "format query", target: "/tx/abc?format=json", accept: "text/html", json: true},
+		{name: "format query html", target: "/tx/abc?format=html", accept: "application/json", json: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			if tt.json {
+				var info model.TxInfo
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+				assert.Equal(t, "abc", info.TxID)
+			} else {
+				assert.Contains(t, w.Body.String(), `<div id="tx-container">`)
+			}
+		})
+	}
+}
+
+func TestContentNegotiationEvents(t *testing.T) {
+	s := &MockStore{
+		events:       make(chan model.Event, 10),
+		searchResult: model.SearchResult{Transactions: []model.Event{{TxID: "found", State: model.StateSubmitted}}},
+	}
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
+	a, err := api.New(s, b)
+	require.NoError(t, err)
+
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+
+	s.events <- model.Event{TxID: "recent", State: model.StateSubmitted}
+	require.Eventually(t, func() bool { return len(b.Recent()) == 1 }, time.Second, time.Millisecond*10)
+
+	for _, target := range []string{"/?format=json", "/api/v1/events?format=json"} {
+		var events []model.Event
+		resp := get(t, a, target)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		assert.Equal(t, "recent", events[0].TxID)
+
+		resp = get(t, a, target+"&q=found")
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &events))
+		require.Len(t, events, 1)
+		assert.Equal(t, "found", events[0].TxID)
+	}
+
+	assert.NoError(t, b.Stop())
+	require.NoError(t, eg.Wait().ErrorOrNil())
+}
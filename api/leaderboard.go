@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+func (a *API) leaderboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.leaderboardJSON(w, r)
+		return
+	}
+
+	sr, err := parseLeaderboardRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	nodes := a.s.CachedLeaderboard(sr)
+	if r.Header.Get("Hx-Request") == "true" {
+		w.Header().Set("HX-Push-Url", r.URL.RequestURI())
+		if err := templates.Leaderboard(nodes, sr).Render(r.Context(), w); err != nil {
+			slog.Error("failed to render leaderboard", slog.Any("err", err))
+		}
+		return
+	}
+
+	push(w)
+	if err := templates.LeaderboardPage(nodes, sr).Render(r.Context(), w); err != nil {
+		slog.Error("failed to render LeaderboardPage", slog.Any("err", err))
+	}
+}
+
+func (a *API) leaderboardJSON(w http.ResponseWriter, r *http.Request) {
+	sr, err := parseLeaderboardRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	nodes := a.s.CachedLeaderboard(sr)
+	if nodes == nil {
+		nodes = []model.NodeRank{}
+	}
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+// parseLeaderboardRange parses stats range of the leaderboard, which defaults
+// to the last week. Only precomputed ranges are supported.
+func parseLeaderboardRange(r *http.Request) (model.StatsRange, error) {
+	v := r.URL.Query().Get("range")
+	if v == "" {
+		return model.RangeWeek, nil
+	}
+
+	sr, err := model.ParseStatsRange(v)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(model.PredefinedStatsRanges(), sr) {
+		return nil, fmt.Errorf("unsupported leaderboard range %s", v)
+	}
+	return sr, nil
+}
@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardJSON(t *testing.T) {
+	s := &MockStore{}
+	a := newTestAPI(t, s)
+
+	// Empty leaderboard is an empty list instead of null.
+	resp := get(t, a, "/api/v2/leaderboard")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[]`, resp.Body.String())
+
+	s.leaderboard = []model.NodeRank{{Node: "n1", Proofs: 3, Verifications: 1, MedianLatency: time.Second, Share: 80}}
+	resp = get(t, a, "/api/v2/leaderboard?range=1m")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"node":"n1","proofs":3,"verifications":1,"median_latency":1000000000,"share":80}]`, resp.Body.String())
+
+	resp = get(t, a, "/api/v2/leaderboard?range=ytd")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = get(t, a, "/leaderboard?format=json")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	assertErrorResponse(t, get(t, a, "/api/v2/leaderboard?range=2w"), http.StatusBadRequest)
+}
+
+func TestLeaderboardHTML(t *testing.T) {
+	s := &MockStore{leaderboard: []model.NodeRank{
+		{Node: "n1", Proofs: 3, Verifications: 1, MedianLatency: 90 * time.Second, Share: 80},
+		{Node: "n2", Verifications: 1, Share: 20},
+	}}
+	a := newTestAPI(t, s)
+
+	r := httptest.NewRequest(http.MethodGet, "/leaderboard?range=6m", nil)
+	r.Header.Set("Hx-Request", "true")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/leaderboard?range=6m", w.Header().Get("HX-Push-Url"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<a class="page-link active" href="/leaderboard?range=6m"`)
+	assert.Contains(t, body, `href="/leaderboard?range=all"`)
+	assert.Contains(t, body, `hx-get="/account/n1"`)
+	assert.Contains(t, body, `<div class="td leaderboard-node">n2</div>`)
+	assert.Contains(t, body, `<div class="td">00H:01M:30S</div>`)
+	assert.Contains(t, body, `<div class="td">80.00%</div>`)
+
+	resp := get(t, a, "/leaderboard")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "<!doctype html>")
+	assert.Contains(t, resp.Body.String(), `<a class="page-link active" href="/leaderboard?range=1w"`)
+
+	resp = get(t, a, "/leaderboard?range=2w")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
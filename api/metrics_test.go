@@ -0,0 +1,22 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	a := newTestAPI(t, &MockStore{})
+
+	// Make one instrumented request so that route histogram gets exported.
+	require.Equal(t, http.StatusOK, get(t, a, "/api/v2/stats?range=1w").Code)
+
+	resp := get(t, a, "/metrics")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, "devnet_explorer_broadcaster_events_broadcast_total")
+	assert.Contains(t, body, `devnet_explorer_http_request_duration_seconds_count{code="200",method="get",route="/api/v2/stats"}`)
+}
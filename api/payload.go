@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+const (
+	DefaultPreviewLength = 1024
+	MaxPreviewLength     = 64 * 1024
+)
+
+// maxPayloadSize is the largest payload Postgres can store, as field values
+// are limited to 1 GiB. Larger offsets are rejected before they reach the
+// store, where they would overflow substring positions.
+const maxPayloadSize = 1 << 30
+
+// Preview encodings.
+const (
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+// payload serves raw payload of proof, verification or proof key transaction
+// as file download.
+func (a *API) payload(w http.ResponseWriter, r *http.Request) {
+	tx := r.PathValue("tx")
+	p, data, err := a.s.Payload(tx, 0, 0)
+	if errors.Is(err, model.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("payload not found"))
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to get payload", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get payload"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s.bin"`, p.TxID, p.Kind))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if _, err := w.Write(data); err != nil {
+		slog.Debug("failed to write payload", slog.Any("err", err))
+	}
+}
+
+func (a *API) previewPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.previewJSON(w, r)
+		return
+	}
+
+	p, err := a.preview(r)
+	var perr previewError
+	switch {
+	case errors.As(err, &perr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, model.ErrNotFound):
+		http.Error(w, "payload not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("failed to get payload", slog.Any("err", err))
+		http.Error(w, "failed to get payload", http.StatusInternalServerError)
+		return
+	}
+
+	if err := templates.PayloadPreview(p).Render(r.Context(), w); err != nil {
+		slog.Error("failed to render PayloadPreview", slog.Any("err", err))
+	}
+}
+
+func (a *API) previewJSON(w http.ResponseWriter, r *http.Request) {
+	p, err := a.preview(r)
+	var perr previewError
+	switch {
+	case errors.As(err, &perr):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, model.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("payload not found"))
+		return
+	case err != nil:
+		slog.Error("failed to get payload", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get payload"))
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// previewError is returned for invalid preview parameters.
+type previewError struct{ msg string }
+
+func (e previewError) Error() string { return e.msg }
+
+// preview reads requested range of the payload and encodes it. Range is
+// limited to MaxPreviewLength bytes so that large proofs aren't loaded whole.
+func (a *API) preview(r *http.Request) (model.PayloadPreview, error) {
+	q := r.URL.Query()
+	p := model.PayloadPreview{Encoding: EncodingHex}
+	if e := q.Get("encoding"); e != "" {
+		if e != EncodingHex && e != EncodingBase64 {
+			return p, previewError{fmt.Sprintf("encoding must be %s or %s", EncodingHex, EncodingBase64)}
+		}
+		p.Encoding = e
+	}
+
+	offset, err := parseIntParam(q, "offset", 0)
+	if err != nil || offset < 0 {
+		return p, previewError{"offset must be a non-negative integer"}
+	}
+	if offset > maxPayloadSize {
+		return p, previewError{fmt.Sprintf("offset is beyond maximum payload size of %d bytes", maxPayloadSize)}
+	}
+
+	length, err := parseIntParam(q, "length", DefaultPreviewLength)
+	if err != nil || length < 1 || length > MaxPreviewLength {
+		return p, previewError{fmt.Sprintf("length must be between 1 and %d", MaxPreviewLength)}
+	}
+
+	payload, data, err := a.s.Payload(r.PathValue("tx"), offset, length)
+	if err != nil {
+		return p, err
+	}
+
+	if offset > payload.Size {
+		return p, previewError{fmt.Sprintf("offset is beyond payload size of %d bytes", payload.Size)}
+	}
+
+	p.Payload = payload
+	p.Offset = offset
+	p.Length = len(data)
+	if p.Encoding == EncodingBase64 {
+		p.Data = base64.StdEncoding.EncodeToString(data)
+	} else {
+		p.Data = hex.EncodeToString(data)
+	}
+	return p, nil
+}
+
+func parseIntParam(q url.Values, key string, def int) (int, error) {
+	v := q.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
@@ -0,0 +1,107 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gevulotnetwork/devnet-explorer/api"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadDownload(t *testing.T) {
+	s := &MockStore{
+		payload:     model.Payload{TxID: "abc", Kind: model.PayloadProof, Size: 4, SHA256: "d1"},
+		payloadData: []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/tx/abc/payload")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/octet-stream", resp.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="abc.proof.bin"`, resp.Header().Get("Content-Disposition"))
+	assert.Equal(t, "4", resp.Header().Get("Content-Length"))
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, resp.Body.Bytes())
+
+	s.payloadErr = fmt.Errorf("payload: %w", model.ErrNotFound)
+	assertErrorResponse(t, get(t, a, "/api/v2/tx/abc/payload"), http.StatusNotFound)
+}
+
+func TestPayloadPreviewJSON(t *testing.T) {
+	s := &MockStore{
+		payload:     model.Payload{TxID: "abc", Kind: model.PayloadVerification, Size: 4, SHA256: "d1"},
+		payloadData: []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/tx/abc/preview")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"tx_id":"abc","kind":"verification","size":4,"sha256":"d1","offset":0,"length":4,"encoding":"hex","data":"deadbeef"}`, resp.Body.String())
+
+	resp = get(t, a, "/api/v2/tx/abc/preview?encoding=base64&offset=1&length=2")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"tx_id":"abc","kind":"verification","size":4,"sha256":"d1","offset":1,"length":2,"encoding":"base64","data":"rb4="}`, resp.Body.String())
+
+	for _, q := range []string{
+		"encoding=utf8",
+		"offset=-1",
+		"offset=x",
+		"offset=5",
+		"offset=2147483647",
+		"offset=9223372036854775807",
+		"offset=99999999999999999999",
+		"length=0",
+		fmt.Sprintf("length=%d", api.MaxPreviewLength+1),
+	} {
+		assertErrorResponse(t, get(t, a, "/api/v2/tx/abc/preview?"+q), http.StatusBadRequest)
+	}
+
+	s.payloadErr = fmt.Errorf("payload: %w", model.ErrNotFound)
+	assertErrorResponse(t, get(t, a, "/api/v2/tx/abc/preview"), http.StatusNotFound)
+}
+
+func TestPayloadPreviewHTML(t *testing.T) {
+	s := &MockStore{
+		txInfo: model.TxInfo{
+			TxID:     "abc",
+			Payloads: []model.Payload{{TxID: "def", Kind: model.PayloadProof, Size: 2048, SHA256: "d1"}},
+		},
+		payload:     model.Payload{TxID: "def", Kind: model.PayloadProof, Size: 2048, SHA256: "d1"},
+		payloadData: make([]byte, 2048),
+	}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/tx/abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `<span class="payload-size">2.0 KiB</span>`)
+	assert.Contains(t, body, `sha256 d1`)
+	assert.Contains(t, body, `href="/api/v2/tx/def/payload"`)
+	assert.Contains(t, body, `hx-get="/tx/def/preview?encoding=base64"`)
+	assert.Contains(t, body, `<div id="preview-def"></div>`)
+
+	// Preview is limited to default length and links to the next range.
+	resp = get(t, a, "/tx/def/preview?encoding=hex")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body = resp.Body.String()
+	assert.Contains(t, body, "<pre>"+strings.Repeat("00", api.DefaultPreviewLength)+"</pre>")
+	assert.Contains(t, body, "bytes 0-1024 of 2048")
+	assert.Contains(t, body, `hx-get="/tx/def/preview?encoding=hex&amp;offset=1024"`)
+
+	resp = get(t, a, "/tx/def/preview?encoding=hex&offset=1024")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "bytes 1024-2048 of 2048")
+	assert.NotContains(t, resp.Body.String(), "More")
+
+	resp = get(t, a, "/tx/def/preview?length=abc")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	// Payload section is left out if there is nothing to show.
+	s.txInfo.Payloads = nil
+	resp = get(t, a, "/tx/abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "tx-payloads")
+}
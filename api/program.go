@@ -0,0 +1,62 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+func (a *API) programPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.programJSON(w, r)
+		return
+	}
+
+	p, err := a.s.Program(r.PathValue("hash"))
+	if errors.Is(err, model.ErrNotFound) {
+		http.Error(w, "program not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to get program", slog.Any("err", err))
+		http.Error(w, "failed to get program", http.StatusInternalServerError)
+		return
+	}
+
+	if r.Header.Get("Hx-Request") == "true" {
+		w.Header().Set("HX-Push-Url", r.URL.EscapedPath())
+		if err := templates.Program(p).Render(r.Context(), w); err != nil {
+			slog.Error("failed to render Program", slog.Any("err", err))
+		}
+		return
+	}
+
+	push(w)
+	if err := templates.ProgramPage(p).Render(r.Context(), w); err != nil {
+		slog.Error("failed to render ProgramPage", slog.Any("err", err))
+	}
+}
+
+func (a *API) programJSON(w http.ResponseWriter, r *http.Request) {
+	p, err := a.s.Program(r.PathValue("hash"))
+	if errors.Is(err, model.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("program not found"))
+		return
+	}
+
+	if err != nil {
+		slog.Error("failed to get program", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to get program"))
+		return
+	}
+
+	if p.RecentRuns == nil {
+		p.RecentRuns = []model.Event{}
+	}
+	writeJSON(w, http.StatusOK, p)
+}
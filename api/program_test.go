@@ -0,0 +1,79 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgramJSON(t *testing.T) {
+	s := &MockStore{program: model.ProgramInfo{
+		Program: model.Program{Hash: "p1", Name: "starknet", Memory: 512, CPUs: 2, DeployTx: "d1", Prover: true},
+		Stats:   model.ProgramStats{Runs: 4, Completed: 3, SuccessRate: 75, AvgLatency: time.Minute},
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/program/p1")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, "p1", body["hash"])
+	assert.Equal(t, "starknet", body["name"])
+	assert.Equal(t, "d1", body["deploy_tx"])
+	assert.Equal(t, true, body["prover"])
+	assert.Equal(t, false, body["verifier"])
+	assert.Equal(t, map[string]any{"runs": 4.0, "completed": 3.0, "success_rate": 75.0, "avg_latency": float64(time.Minute)}, body["stats"])
+	assert.Equal(t, []any{}, body["recent_runs"])
+
+	resp = get(t, a, "/program/p1?format=json")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+
+	s.programErr = fmt.Errorf("program p2: %w", model.ErrNotFound)
+	assertErrorResponse(t, get(t, a, "/api/v2/program/p2"), http.StatusNotFound)
+
+	s.programErr = errors.New("db down")
+	assertErrorResponse(t, get(t, a, "/api/v2/program/p1"), http.StatusInternalServerError)
+}
+
+func TestProgramHTML(t *testing.T) {
+	s := &MockStore{program: model.ProgramInfo{
+		Program:    model.Program{Hash: "p1", Name: "starknet", ImageFileName: "prover.img", Verifier: true},
+		Stats:      model.ProgramStats{Runs: 2, Completed: 1, SuccessRate: 50},
+		RecentRuns: []model.Event{{State: model.StateComplete, TxID: "tx1", ProverID: "p1"}},
+	}}
+	a := newTestAPI(t, s)
+
+	r := httptest.NewRequest(http.MethodGet, "/program/p1", nil)
+	r.Header.Set("Hx-Request", "true")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/program/p1", w.Header().Get("HX-Push-Url"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<div class="tx-id-block-value">prover.img</div>`)
+	assert.Contains(t, body, `<div class="info-stat-value">Verifier</div>`)
+	assert.Contains(t, body, `<div class="info-stat-value">50.00%</div>`)
+	assert.Contains(t, body, `<div id="tx1" class="tr"`)
+	assert.Contains(t, body, `hx-get="/transactions?kind=run&amp;prover=p1"`)
+	assert.Contains(t, body, `<a id="back-x" href="/"`)
+	assert.NotContains(t, body, "<!doctype html>")
+
+	resp := get(t, a, "/program/p1")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "<!doctype html>")
+
+	s.programErr = model.ErrNotFound
+	resp = get(t, a, "/program/p2")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
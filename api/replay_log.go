@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+// replayLog keeps the most recently broadcasted events in id order so that
+// reconnecting clients can receive exactly the events they missed.
+type replayLog struct {
+	entries []logEntry
+	next    int
+	count   int
+}
+
+type logEntry struct {
+	id    uint64
+	event model.Event
+	data  encodedEvent
+}
+
+func newReplayLog(size uint) *replayLog {
+	return &replayLog{
+		entries: make([]logEntry, size),
+	}
+}
+
+func (l *replayLog) add(id uint64, e model.Event, data encodedEvent) {
+	l.entries[l.next] = logEntry{id: id, event: e, data: data}
+	l.next = (l.next + 1) % len(l.entries)
+	l.count = min(l.count+1, len(l.entries))
+}
+
+// since returns entries broadcasted after lastID in id order. It returns false
+// if events following lastID are no longer held by the log.
+func (l *replayLog) since(lastID uint64) ([]logEntry, bool) {
+	if l.count == 0 {
+		return nil, false
+	}
+
+	oldest := l.entries[(l.next-l.count+len(l.entries))%len(l.entries)].id
+	newest := l.entries[(l.next-1+len(l.entries))%len(l.entries)].id
+	if lastID+1 < oldest || lastID > newest {
+		return nil, false
+	}
+
+	missed := int(newest - lastID)
+	entries := make([]logEntry, 0, missed)
+	for i := l.count - missed; i < l.count; i++ {
+		entries = append(entries, l.entries[(l.next-l.count+i+len(l.entries))%len(l.entries)])
+	}
+	return entries, true
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchResultsHTML(t *testing.T) {
+	s := &MockStore{searchResult: model.SearchResult{
+		Programs:     []model.ProgramMatch{{Hash: "p1", Name: "Starknet"}},
+		Accounts:     []model.AccountMatch{{Key: "04ff", Transactions: 3, LastSeen: time.Now()}},
+		Transactions: []model.Event{{TxID: "tx1", State: model.StateSubmitted}},
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v1/events?q=stark")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `<div class="search-group-header">Programs</div>`)
+	assert.Contains(t, body, `hx-get="/program/p1"`)
+	assert.Contains(t, body, `<span class="provider-tag">#Starknet</span>`)
+	assert.Contains(t, body, `<div class="search-group-header">Accounts</div>`)
+	assert.Contains(t, body, `hx-get="/account/04ff"`)
+	assert.Contains(t, body, `<div id="tx1" class="tr"`)
+
+	// Groups are left out when only transactions match.
+	s.searchResult = model.SearchResult{Transactions: s.searchResult.Transactions}
+	resp = get(t, a, "/api/v1/events?q=tx1")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "search-groups")
+	assert.Contains(t, resp.Body.String(), `<div id="tx1" class="tr"`)
+}
+
+func TestSearchQueryTimes(t *testing.T) {
+	s := &MockStore{}
+	a := newTestAPI(t, s)
+
+	const q = "?q=after%3A2024-03-01T10%3A00%3A00Z+before%3A2024-03-02T12%3A00%3A00Z+ABCD"
+	since := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	for _, path := range []string{"/api/v2/search", "/api/v1/events"} {
+		s.searchFilter = model.TxFilter{}
+		resp := get(t, a, path+q)
+		require.Equal(t, http.StatusOK, resp.Code, path)
+		assert.Equal(t, since, s.searchFilter.Since, path)
+		assert.Equal(t, until, s.searchFilter.Until, path)
+		assert.Equal(t, "abcd", s.searchFilter.Text, path)
+	}
+
+	for _, path := range []string{"/api/v2/transactions", "/transactions"} {
+		s.txFilter = model.TxFilter{}
+		resp := get(t, a, path+q)
+		require.Equal(t, http.StatusOK, resp.Code, path)
+		assert.Equal(t, since, s.txFilter.Since, path)
+		assert.Equal(t, until, s.txFilter.Until, path)
+	}
+}
+
+func TestSearchNotStreamable(t *testing.T) {
+	s := &MockStore{searchResult: model.SearchResult{
+		Transactions: []model.Event{{TxID: "tx1", Kind: model.KindProof, State: model.StateProving}},
+	}}
+	a := newTestAPI(t, s)
+
+	// Live events are runs, so proofs are listed without streaming.
+	resp := get(t, a, "/api/v1/events?q=kind%3Aproof")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `<div id="tx1" class="tr"`)
+	assert.NotContains(t, resp.Body.String(), "sse-connect")
+
+	resp = get(t, a, "/api/v1/events?q=kind%3Arun")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `sse-connect="/api/v1/stream?q=kind%3Arun&amp;since=`)
+
+	assert.Equal(t, http.StatusBadRequest, get(t, a, "/api/v1/stream?q=kind%3Aproof").Code)
+}
@@ -1,7 +1,10 @@
 package api_test
 
 import (
+	"bufio"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -22,7 +25,7 @@ func TestServerMultipleClients(t *testing.T) {
 	)
 
 	s := &MockStore{events: make(chan model.Event, numOfEvents+1)}
-	b := api.NewBroadcaster(s, time.Second)
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
 	srv, err := api.NewServer("127.0.0.1:7645", s, b)
 	require.NoError(t, err)
 	r := app.NewRunner(b, srv)
@@ -73,3 +76,35 @@ func consume(t *testing.T, num int, done chan struct{}) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestServerHeartbeat(t *testing.T) {
+	s := &MockStore{events: make(chan model.Event, 10)}
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
+	a, err := api.New(s, b, api.WithHeartbeatInterval(50*time.Millisecond), api.WithClientRetry(time.Second))
+	require.NoError(t, err)
+
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	assert.Equal(t, "retry: 1000", scanner.Text())
+
+	heartbeats := 0
+	for scanner.Scan() && heartbeats < 2 {
+		if scanner.Text() == ": heartbeat" {
+			heartbeats++
+		}
+	}
+	assert.Equal(t, 2, heartbeats)
+
+	assert.NoError(t, b.Stop())
+	require.NoError(t, eg.Wait().ErrorOrNil())
+}
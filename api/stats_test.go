@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/api"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsSeriesJSON(t *testing.T) {
+	s := &MockStore{}
+	a := newTestAPI(t, s)
+
+	// Empty series is an empty list instead of null.
+	resp := get(t, a, "/api/v2/stats/series?range=1w")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[]`, resp.Body.String())
+	assert.Equal(t, model.BucketDay, s.bucket)
+
+	ts := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	s.series = []model.Stats{{CreatedAt: ts, RegisteredUsers: 1, ProofsGenerated: 2, ProversDeployed: 3, ProofsVerified: 4}}
+	resp = get(t, a, "/api/v2/stats/series?range=6m&bucket=Week")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"created_at":"2024-03-04T00:00:00Z","registered_users":1,"proofs_generated":2,"programs":3,"proofs_verified":4}]`, resp.Body.String())
+	assert.Equal(t, model.BucketWeek, s.bucket)
+
+	assertErrorResponse(t, get(t, a, "/api/v2/stats/series?range=2x"), http.StatusBadRequest)
+	assertErrorResponse(t, get(t, a, "/api/v2/stats/series?range=1w&bucket=hour"), http.StatusBadRequest)
+
+	s.seriesErr = errors.New("db down")
+	assertErrorResponse(t, get(t, a, "/api/v2/stats/series?range=1w"), http.StatusInternalServerError)
+}
+
+func TestStatsCustomRange(t *testing.T) {
+	s := &MockStore{}
+	a := newTestAPI(t, s)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "range=1w", want: "1w"},
+		{query: "range=3d", want: "3d"},
+		{query: "range=ytd", want: "ytd"},
+		{query: "range=all", want: "all"},
+		{query: "range=2024-03-01..2024-04-01", want: "2024-03-01..2024-04-01"},
+		{query: "from=2024-03-01&to=2024-04-01", want: "2024-03-01..2024-04-01"},
+		{query: "range=custom&from=2024-03-01&to=", want: "2024-03-01.."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp := get(t, a, "/api/v2/stats?"+tt.query)
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.want, s.statsRange.String())
+
+			resp = get(t, a, "/api/v1/stats?"+tt.query)
+			require.Equal(t, http.StatusOK, resp.Code)
+		})
+	}
+
+	for _, q := range []string{
+		"",
+		"range=0d",
+		"range=custom",
+		"from=2024-04-01&to=2024-03-01",
+		"from=yesterday",
+	} {
+		assertErrorResponse(t, get(t, a, "/api/v2/stats?"+q), http.StatusBadRequest)
+		assert.Equal(t, http.StatusBadRequest, get(t, a, "/api/v1/stats?"+q).Code)
+	}
+
+	s.statsErr = errors.New("db down")
+	assertErrorResponse(t, get(t, a, "/api/v2/stats?range=3d"), http.StatusInternalServerError)
+	assert.Equal(t, http.StatusInternalServerError, get(t, a, "/api/v1/stats?range=3d").Code)
+}
+
+func TestStatsSparklines(t *testing.T) {
+	s := &MockStore{series: []model.Stats{
+		{ProofsGenerated: 10, RegisteredUsers: 5},
+		{ProofsGenerated: 20, RegisteredUsers: 5},
+		{ProofsGenerated: 15, RegisteredUsers: 5},
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v1/stats?range=1m")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `<polyline points="0.00,20.00 50.00,0.00 100.00,10.00"></polyline>`)
+	// Flat series is drawn in the middle.
+	assert.Contains(t, body, `<polyline points="0.00,10.00 50.00,10.00 100.00,10.00"></polyline>`)
+
+	// Single point isn't enough for a chart.
+	s.series = s.series[:1]
+	resp = get(t, a, "/api/v1/stats?range=1m")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "sparkline")
+}
+
+func TestHourlyStatsJSON(t *testing.T) {
+	s := &MockStore{}
+	a := newTestAPI(t, s)
+
+	// Defaults to the last 24 hours.
+	resp := get(t, a, "/api/v2/stats/hourly")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[]`, resp.Body.String())
+	assert.WithinDuration(t, time.Now(), s.until, time.Minute)
+	assert.Equal(t, api.DefaultHourlyStatsPeriod, s.until.Sub(s.since))
+
+	hour := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.hourly = []model.HourlyStats{{Hour: hour, Runs: 1, Proofs: 2, Verifications: 3, LatencyP50: time.Second, LatencyP95: 2 * time.Second}}
+	resp = get(t, a, "/api/v2/stats/hourly?since=2024-03-01&until=2024-03-02")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"hour":"2024-03-01T12:00:00Z","runs":1,"proofs":2,"verifications":3,"latency_p50":1000000000,"latency_p95":2000000000}]`, resp.Body.String())
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), s.since)
+	assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), s.until)
+
+	for _, q := range []string{
+		"since=yesterday",
+		"until=tomorrow",
+		"since=2024-03-02&until=2024-03-01",
+	} {
+		assertErrorResponse(t, get(t, a, "/api/v2/stats/hourly?"+q), http.StatusBadRequest)
+	}
+
+	s.hourlyErr = errors.New("db down")
+	assertErrorResponse(t, get(t, a, "/api/v2/stats/hourly"), http.StatusInternalServerError)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
+)
+
+const DefaultQueueSize = ReplaySize
+
+// SlowClientPolicy defines what broadcaster does when subscriber's send queue is full.
+type SlowClientPolicy uint8
+
+const (
+	// PolicyDropOldest drops the oldest queued event to make room for the new one.
+	PolicyDropOldest SlowClientPolicy = iota
+	// PolicyDisconnect closes the subscription.
+	PolicyDisconnect
+	// PolicyResync drops all queued events and sends resync event telling
+	// client to reload its state.
+	PolicyResync
+)
+
+func (p SlowClientPolicy) String() string {
+	switch p {
+	case PolicyDropOldest:
+		return "drop-oldest"
+	case PolicyDisconnect:
+		return "disconnect"
+	case PolicyResync:
+		return "resync"
+	default:
+		return ""
+	}
+}
+
+// Decode implements envconfig.Decoder.
+func (p *SlowClientPolicy) Decode(value string) error {
+	policy, err := ParseSlowClientPolicy(value)
+	if err != nil {
+		return err
+	}
+	*p = policy
+	return nil
+}
+
+func ParseSlowClientPolicy(s string) (SlowClientPolicy, error) {
+	switch strings.ToLower(s) {
+	case "drop-oldest":
+		return PolicyDropOldest, nil
+	case "disconnect":
+		return PolicyDisconnect, nil
+	case "resync":
+		return PolicyResync, nil
+	default:
+		return 0, fmt.Errorf("invalid slow client policy: %q", s)
+	}
+}
+
+// SubscriberStats describes state of a single subscriber.
+type SubscriberStats struct {
+	ID      uint64 `json:"id"`
+	Format  Format `json:"format"`
+	Queued  int    `json:"queued"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// subscriber has its own send queue which is drained into ch by a dedicated
+// goroutine, so broadcaster never blocks on a slow client.
+type subscriber struct {
+	id     uint64
+	filter Filter
+	format Format
+	policy SlowClientPolicy
+	limit  int
+
+	ch        chan []byte
+	wake      chan struct{}
+	done      chan struct{}
+	exited    chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	queue   [][]byte
+	dropped atomic.Uint64
+}
+
+func newSubscriber(id uint64, f Filter, format Format, policy SlowClientPolicy, limit int) *subscriber {
+	s := &subscriber{
+		id:     id,
+		filter: f,
+		format: format,
+		policy: policy,
+		limit:  limit,
+		ch:     make(chan []byte),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// send adds data to send queue applying slow client policy when the queue is
+// full. It returns false if subscriber must be disconnected.
+func (s *subscriber) send(data []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) >= s.limit {
+		switch s.policy {
+		case PolicyDisconnect:
+			s.drop(1)
+			return false
+		case PolicyResync:
+			s.drop(len(s.queue) + 1)
+			s.queue = append(s.queue[:0], resyncFrame(s.format))
+			s.notify()
+			return true
+		default:
+			s.drop(1)
+			s.queue = s.queue[1:]
+		}
+	}
+
+	s.queue = append(s.queue, data)
+	s.notify()
+	return true
+}
+
+// sendAll adds data to send queue regardless of the queue limit.
+func (s *subscriber) sendAll(data [][]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, data...)
+	s.notify()
+}
+
+func (s *subscriber) drop(n int) {
+	s.dropped.Add(uint64(n))
+	metrics.EventsDropped.Add(float64(n))
+}
+
+func (s *subscriber) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscriber) next() ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+	data := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+	return data, true
+}
+
+func (s *subscriber) run() {
+	defer close(s.exited)
+	defer close(s.ch)
+	for {
+		data, ok := s.next()
+		if !ok {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		select {
+		case s.ch <- data:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// close stops the subscriber, ch is closed once the send goroutine has exited.
+func (s *subscriber) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *subscriber) stats() SubscriberStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SubscriberStats{
+		ID:      s.id,
+		Format:  s.format,
+		Queued:  len(s.queue),
+		Dropped: s.dropped.Load(),
+	}
+}
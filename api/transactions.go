@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gevulotnetwork/devnet-explorer/api/templates"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+func (a *API) transactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+	if wantsJSON(r) {
+		a.transactionsJSON(w, r)
+		return
+	}
+
+	f, p, err := a.parseTxQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := a.s.Transactions(f, p)
+	if err != nil {
+		// Same as with search, render empty page instead of failing.
+		slog.Error("failed to list transactions", slog.Any("err", err))
+	}
+
+	if r.Header.Get("Hx-Request") == "true" {
+		w.Header().Set("HX-Push-Url", r.URL.RequestURI())
+		if err := templates.History(page, r.URL.Query()).Render(r.Context(), w); err != nil {
+			slog.Error("failed to render history", slog.Any("err", err))
+		}
+		return
+	}
+
+	push(w)
+	if err := templates.HistoryPage(page, r.URL.Query()).Render(r.Context(), w); err != nil {
+		slog.Error("failed to render HistoryPage", slog.Any("err", err))
+	}
+}
+
+func (a *API) transactionsJSON(w http.ResponseWriter, r *http.Request) {
+	f, p, err := a.parseTxQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := a.s.Transactions(f, p)
+	if err != nil {
+		slog.Error("failed to list transactions", slog.Any("err", err))
+		writeError(w, http.StatusInternalServerError, errors.New("failed to list transactions"))
+		return
+	}
+
+	if page.Events == nil {
+		page.Events = []model.Event{}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// parseTxQuery parses transaction filter and page from query parameters.
+// Filter is parsed from search query 'q' first and then other filter parameters
+// override its fields.
+func (a *API) parseTxQuery(q url.Values) (f model.TxFilter, p model.Page, err error) {
+	if f, err = model.ParseSearchQuery(q.Get("q"), a.minPrefixLen); err != nil {
+		return f, p, err
+	}
+
+	if s := q.Get("state"); s != "" {
+		if f.State, err = model.ParseState(s); err != nil {
+			return f, p, err
+		}
+	}
+
+	if s := q.Get("kind"); s != "" {
+		if f.Kind, err = model.ParseTxKind(s); err != nil {
+			return f, p, err
+		}
+	}
+
+	// Hashes are case insensitive and matched as prefixes like in search
+	// queries.
+	setHash := func(field *string, prefix *bool, key string) {
+		if v := q.Get(key); v != "" {
+			*field, *prefix = strings.ToLower(v), len(v) >= a.minPrefixLen
+		}
+	}
+	setHash(&f.Prover, &f.ProverPrefix, "prover")
+	setHash(&f.Author, &f.AuthorPrefix, "author")
+	if v := q.Get("tag"); v != "" {
+		f.Tag = v
+	}
+
+	if s := q.Get("since"); s != "" {
+		if f.Since, err = model.ParseTime(s); err != nil {
+			return f, p, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if s := q.Get("until"); s != "" {
+		if f.Until, err = model.ParseTime(s); err != nil {
+			return f, p, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+
+	if p.Before, err = model.ParseTxCursor(q.Get("before")); err != nil {
+		return f, p, err
+	}
+	if p.After, err = model.ParseTxCursor(q.Get("after")); err != nil {
+		return f, p, err
+	}
+	if !p.Before.IsZero() && !p.After.IsZero() {
+		return f, p, errors.New("before and after can't be used together")
+	}
+
+	p.Limit = DefaultPageSize
+	if l := q.Get("limit"); l != "" {
+		if p.Limit, err = strconv.Atoi(l); err != nil || p.Limit < 1 || p.Limit > MaxPageSize {
+			return f, p, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+		}
+	}
+	return f, p, nil
+}
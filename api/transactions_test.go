@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionsJSON(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cursor := model.TxCursor{Timestamp: ts, TxID: "abc"}
+	s := &MockStore{txPage: model.TxPage{
+		Events: []model.Event{{State: model.StateProving, TxID: "abc", Kind: "proof", Timestamp: ts}},
+		Older:  cursor.String(),
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/api/v2/transactions?state=proving&kind=proof&prover=p1&author=a1&since=2024-03-01&until=2024-03-02T00:00:00Z&before="+cursor.String()+"&limit=10")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, model.TxFilter{
+		State:  model.StateProving,
+		Kind:   "proof",
+		Prover: "p1",
+		Author: "a1",
+		Since:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Until:  time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+	}, s.txFilter)
+
+	assert.True(t, s.page.Before.Timestamp.Equal(ts))
+	assert.Equal(t, "abc", s.page.Before.TxID)
+	assert.Equal(t, 10, s.page.Limit)
+
+	var page struct {
+		Events []map[string]any `json:"events"`
+		Older  string           `json:"older"`
+		Newer  string           `json:"newer"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &page))
+	require.Len(t, page.Events, 1)
+	assert.Equal(t, "proving", page.Events[0]["state"])
+	assert.Equal(t, "proof", page.Events[0]["kind"])
+	assert.Equal(t, cursor.String(), page.Older)
+	assert.Empty(t, page.Newer)
+
+	// Defaults
+	resp = get(t, a, "/api/v2/transactions")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, model.TxFilter{}, s.txFilter)
+	assert.Equal(t, model.Page{Limit: 50}, s.page)
+
+	// Long enough hashes are matched as prefixes.
+	resp = get(t, a, "/api/v2/transactions?prover=P1234&author=04ff")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, model.TxFilter{Prover: "p1234", ProverPrefix: true, Author: "04ff", AuthorPrefix: true}, s.txFilter)
+
+	for _, q := range []string{
+		"state=foo",
+		"kind=foo",
+		"since=yesterday",
+		"before=foo",
+		"limit=0",
+		"limit=101",
+		"before=1_a&after=1_b",
+	} {
+		assertErrorResponse(t, get(t, a, "/api/v2/transactions?"+q), http.StatusBadRequest)
+	}
+
+	s.txPage, s.txPageErr = model.TxPage{}, errors.New("db down")
+	assertErrorResponse(t, get(t, a, "/api/v2/transactions"), http.StatusInternalServerError)
+
+	// Empty page has empty list of events instead of null.
+	s.txPageErr = nil
+	resp = get(t, a, "/api/v2/transactions")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"events":[]}`, resp.Body.String())
+}
+
+func TestTransactionsHTML(t *testing.T) {
+	older := model.TxCursor{Timestamp: time.UnixMicro(1), TxID: "old"}
+	newer := model.TxCursor{Timestamp: time.UnixMicro(2), TxID: "new"}
+	s := &MockStore{txPage: model.TxPage{
+		Events: []model.Event{{State: model.StateComplete, TxID: "tx1", Kind: "verification"}},
+		Older:  older.String(),
+		Newer:  newer.String(),
+	}}
+	a := newTestAPI(t, s)
+
+	r := httptest.NewRequest(http.MethodGet, "/transactions?kind=verification&before=3_x", nil)
+	r.Header.Set("Hx-Request", "true")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/transactions?kind=verification&before=3_x", w.Header().Get("HX-Push-Url"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<div id="tx1" class="tr"`)
+	assert.Contains(t, body, `<span class="kind-tag">verification</span>`)
+	assert.Contains(t, body, `hx-get="/transactions?before=1_old&amp;kind=verification"`)
+	assert.Contains(t, body, `hx-get="/transactions?after=2_new&amp;kind=verification"`)
+
+	// Full page is rendered for non-htmx requests.
+	resp := get(t, a, "/transactions")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "<!doctype html>")
+
+	resp = get(t, a, "/transactions?format=json")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+}
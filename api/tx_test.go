@@ -0,0 +1,90 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxWorkflowHTML(t *testing.T) {
+	s := &MockStore{txInfo: model.TxInfo{
+		TxID:     "abc",
+		UserID:   "04ff",
+		ProverID: "p1",
+		Workflow: []model.WorkflowStep{
+			{
+				Sequence:    1,
+				Program:     "p1",
+				ProgramName: "starknet",
+				Args:        []string{"--mode", "prove"},
+				Inputs:      []model.InputFile{{Name: "in.json", URL: "http://files/in.json", Checksum: "c1"}},
+			},
+			{
+				Sequence: 2,
+				Program:  "p2",
+				Outputs:  []model.OutputFile{{Name: "out.bin", SourceProgram: "p1"}},
+			},
+		},
+		Files: []model.TxFile{{TxID: "def", Name: "a.bin", URL: "javascript:alert(1)", Checksum: "c2"}},
+	}}
+	a := newTestAPI(t, s)
+
+	r := httptest.NewRequest(http.MethodGet, "/tx/abc", nil)
+	r.Header.Set("Hx-Request", "true")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `<a class="tx-id-block-value" href="/account/04ff">04ff</a>`)
+	assert.Contains(t, body, `<a class="tx-id-block-value" href="/program/p1">p1</a>`)
+	assert.Contains(t, body, `<span class="provider-tag">#starknet</span>`)
+	assert.Contains(t, body, `<a class="workflow-program" href="/program/p2">p2</a>`)
+	assert.Contains(t, body, `<code class="workflow-args">--mode prove</code>`)
+	assert.Contains(t, body, `<a href="http://files/in.json" target="_blank">in.json</a>`)
+	assert.Contains(t, body, `<span>out.bin</span>`)
+	assert.Contains(t, body, `from p1`)
+	assert.Contains(t, body, `tx def`)
+	// Unsafe attachment URLs are not rendered as links.
+	assert.NotContains(t, body, "javascript:")
+
+	// Workflow section is left out if there is nothing to show.
+	s.txInfo.Workflow, s.txInfo.Files = nil, nil
+	resp := get(t, a, "/tx/abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "tx-workflow")
+}
+
+func TestTxStandaloneHTML(t *testing.T) {
+	s := &MockStore{txInfo: model.TxInfo{
+		State:      model.StateComplete,
+		Kind:       model.KindDeploy,
+		TxID:       "abc",
+		UserID:     "04ff",
+		ProverID:   "p1",
+		Deployment: &model.Deployment{Name: "starknet", Prover: "p1", Verifier: "v1"},
+		Log:        []model.TxLogEvent{{State: model.StateComplete, IDType: "user id", ID: "04ff"}},
+	}}
+	a := newTestAPI(t, s)
+
+	resp := get(t, a, "/tx/abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body := resp.Body.String()
+	assert.Contains(t, body, `<span class="kind-tag">deploy</span>`)
+	assert.Contains(t, body, `<span class="provider-tag">#starknet</span>`)
+	assert.Contains(t, body, `<a class="tx-id-block-value" href="/program/p1">p1</a>`)
+	assert.Contains(t, body, `<a class="tx-id-block-value" href="/program/v1">v1</a>`)
+
+	// Transfers have no programs.
+	s.txInfo = model.TxInfo{State: model.StateComplete, Kind: model.KindTransfer, TxID: "abc", UserID: "04ff"}
+	resp = get(t, a, "/tx/abc")
+	require.Equal(t, http.StatusOK, resp.Code)
+	body = resp.Body.String()
+	assert.Contains(t, body, `<span class="kind-tag">transfer</span>`)
+	assert.NotContains(t, body, "Prover ID")
+	assert.NotContains(t, body, "Verifier ID")
+}
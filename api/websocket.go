@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 2 * wsPingInterval
+	wsWriteWait    = 10 * time.Second
+	wsMaxMsgSize   = 4096
+)
+
+// Message types of the websocket protocol.
+const (
+	wsTypeSubscribe    = "subscribe"
+	wsTypeUnsubscribe  = "unsubscribe"
+	wsTypePing         = "ping"
+	wsTypePong         = "pong"
+	wsTypeSubscribed   = "subscribed"
+	wsTypeUnsubscribed = "unsubscribed"
+	wsTypeEvent        = "event"
+	wsTypeResync       = "resync"
+	wsTypeError        = "error"
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsClientMessage is a message sent by websocket client. Subscribe message
+// replaces current subscription, so filter can be changed without reconnecting.
+type wsClientMessage struct {
+	Type    string `json:"type"`
+	Q       string `json:"q"`
+	Prover  string `json:"prover"`
+	State   string `json:"state"`
+	Prefill bool   `json:"prefill"`
+
+	// err is set if client sent message that could not be decoded.
+	err error
+}
+
+type wsServerMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error,omitempty"`
+}
+
+type wsEvent struct {
+	Type  string       `json:"type"`
+	ID    uint64       `json:"id"`
+	Event *model.Event `json:"event"`
+}
+
+type wsSession struct {
+	conn         *websocket.Conn
+	b            *Broadcaster
+	minPrefixLen int
+	events       <-chan []byte
+	unsubscribe  func()
+}
+
+func (a *API) websocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader has already responded to the client.
+		slog.Error("failed to upgrade websocket connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
+		return
+	}
+	defer conn.Close()
+
+	slog.Info("websocket client connected", slog.String("remote_addr", r.RemoteAddr))
+	s := &wsSession{conn: conn, b: a.b, minPrefixLen: a.minPrefixLen}
+	defer s.stop()
+
+	done := make(chan struct{})
+	defer close(done)
+	msgs := s.readMessages(done)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case m, ok := <-msgs:
+			if !ok {
+				slog.Info("websocket client disconnected", slog.String("remote_addr", r.RemoteAddr))
+				return
+			}
+			if err := s.handle(m); err != nil {
+				slog.Error("failed to write to websocket client, closing connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
+				return
+			}
+		case data, ok := <-s.events:
+			if !ok {
+				slog.Info("websocket subscription closed by broadcaster, closing connection", slog.String("remote_addr", r.RemoteAddr))
+				return
+			}
+			if err := s.write(data); err != nil {
+				slog.Error("failed to write to websocket client, closing connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				slog.Info("websocket ping failed, closing connection", slog.String("remote_addr", r.RemoteAddr), slog.Any("err", err))
+				return
+			}
+		case <-a.b.done:
+			slog.Info("broadcaster stopped, closing websocket connection", slog.String("remote_addr", r.RemoteAddr))
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait)) // nolint:errcheck
+			return
+		}
+	}
+}
+
+// readMessages reads client messages until connection fails. Returned channel
+// is closed when reading stops.
+func (s *wsSession) readMessages(done <-chan struct{}) <-chan wsClientMessage {
+	msgs := make(chan wsClientMessage)
+	s.conn.SetReadLimit(wsMaxMsgSize)
+	s.conn.SetReadDeadline(time.Now().Add(wsPongWait)) // nolint:errcheck
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	go func() {
+		defer close(msgs)
+		for {
+			_, data, err := s.conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var m wsClientMessage
+			if err := json.Unmarshal(data, &m); err != nil {
+				m = wsClientMessage{err: err}
+			}
+			s.conn.SetReadDeadline(time.Now().Add(wsPongWait)) // nolint:errcheck
+
+			select {
+			case msgs <- m:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return msgs
+}
+
+func (s *wsSession) handle(m wsClientMessage) error {
+	if m.err != nil {
+		return s.reply(wsServerMessage{Type: wsTypeError, Error: "invalid message: " + m.err.Error()})
+	}
+
+	switch m.Type {
+	case wsTypeSubscribe:
+		f, err := m.filter(s.minPrefixLen)
+		if err != nil {
+			return s.reply(wsServerMessage{Type: wsTypeError, Error: err.Error()})
+		}
+		s.stop()
+		s.events, s.unsubscribe = s.b.Subscribe(f, FormatWebSocket, m.Prefill)
+		return s.reply(wsServerMessage{Type: wsTypeSubscribed})
+	case wsTypeUnsubscribe:
+		s.stop()
+		return s.reply(wsServerMessage{Type: wsTypeUnsubscribed})
+	case wsTypePing:
+		return s.reply(wsServerMessage{Type: wsTypePong})
+	default:
+		return s.reply(wsServerMessage{Type: wsTypeError, Error: fmt.Sprintf("invalid message type: %q", m.Type)})
+	}
+}
+
+// stop ends current subscription if there is one.
+func (s *wsSession) stop() {
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+	s.events = nil
+	s.unsubscribe = nil
+}
+
+func (s *wsSession) reply(m wsServerMessage) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.write(data)
+}
+
+func (s *wsSession) write(data []byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (m wsClientMessage) filter(minPrefixLen int) (Filter, error) {
+	f, err := model.ParseSearchQuery(m.Q, minPrefixLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.State != "" {
+		if f.State, err = model.ParseState(m.State); err != nil {
+			return nil, err
+		}
+	}
+	if m.Prover != "" {
+		f.Prover, f.ProverPrefix = strings.ToLower(m.Prover), len(m.Prover) >= minPrefixLen
+	}
+	if !f.Streamable() {
+		return nil, errNotStreamable
+	}
+
+	return f.Match, nil
+}
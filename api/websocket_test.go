@@ -0,0 +1,83 @@
+package api_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/api"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type wsMessage struct {
+	Type  string      `json:"type"`
+	Error string      `json:"error"`
+	ID    uint64      `json:"id"`
+	Event model.Event `json:"event"`
+}
+
+func TestWebSocket(t *testing.T) {
+	s := &MockStore{events: make(chan model.Event, 1000)}
+	b := api.NewBroadcaster(s, api.PolicyDropOldest, api.DefaultQueueSize)
+	a, err := api.New(s, b)
+	require.NoError(t, err)
+
+	eg := &multierror.Group{}
+	eg.Go(b.Run)
+
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "ping"}))
+	assert.Equal(t, "pong", readWS(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "state": "proving"}))
+	assert.Equal(t, "subscribed", readWS(t, conn).Type)
+
+	s.events <- model.Event{TxID: "1", State: model.StateSubmitted}
+	s.events <- model.Event{TxID: "1", State: model.StateProving}
+	m := readWS(t, conn)
+	assert.Equal(t, "event", m.Type)
+	assert.Equal(t, "1", m.Event.TxID)
+	assert.Equal(t, model.StateProving, m.Event.State)
+	assert.NotZero(t, m.ID)
+
+	// Change filter without reconnecting.
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "q": "abc"}))
+	assert.Equal(t, "subscribed", readWS(t, conn).Type)
+
+	s.events <- model.Event{TxID: "2", State: model.StateProving}
+	s.events <- model.Event{TxID: "abc", State: model.StateSubmitted, Timestamp: time.Now()}
+	m = readWS(t, conn)
+	assert.Equal(t, "event", m.Type)
+	assert.Equal(t, "abc", m.Event.TxID)
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "unsubscribe"}))
+	assert.Equal(t, "unsubscribed", readWS(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"type": "subscribe", "state": "invalid"}))
+	assert.Equal(t, "error", readWS(t, conn).Type)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+	assert.Equal(t, "error", readWS(t, conn).Type)
+
+	assert.NoError(t, b.Stop())
+	require.NoError(t, eg.Wait().ErrorOrNil())
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	var m wsMessage
+	require.NoError(t, conn.ReadJSON(&m))
+	return m
+}
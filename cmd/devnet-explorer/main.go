@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	_ "time/tzdata"
@@ -18,6 +19,16 @@ func init() {
 
 func main() {
 	if len(os.Args) > 1 {
+		if os.Args[1] == "backfill" {
+			if err := app.Backfill(os.Args[2:]...); err != nil {
+				slog.Error("backfilling stats failed", slog.Any("error", err))
+				os.Exit(1)
+			}
+			return
+		}
+
+		fmt.Println("Usage: devnet-explorer [backfill <from> [<to>]]")
+		fmt.Println()
 		envconfig.Usagef("", &app.Config{}, os.Stdout, envconfig.DefaultListFormat) // nolint: errcheck
 		return
 	}
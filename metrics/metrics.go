@@ -0,0 +1,98 @@
+// Package metrics defines Prometheus metrics collected by the application.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "devnet_explorer"
+
+var (
+	Subscribers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "broadcaster",
+		Name:      "subscribers",
+		Help:      "Number of connected event stream subscribers.",
+	}, []string{"format"})
+
+	EventsBroadcast = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "broadcaster",
+		Name:      "events_broadcast_total",
+		Help:      "Number of events broadcasted to subscribers.",
+	})
+
+	EventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "broadcaster",
+		Name:      "events_dropped_total",
+		Help:      "Number of events dropped because subscriber was too slow.",
+	})
+
+	SubscribersDisconnected = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "broadcaster",
+		Name:      "subscribers_disconnected_total",
+		Help:      "Number of subscribers disconnected because they were too slow.",
+	})
+
+	EventsReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "events_received_total",
+		Help:      "Number of events received from database notifications.",
+	})
+
+	ListenerReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "listener_reconnects_total",
+		Help:      "Number of times database notification listener has reconnected.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database queries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	CacheRefreshDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "refresh_duration_seconds",
+		Help:      "Duration of cache refreshes.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"cache"})
+
+	CacheRefreshFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "refresh_failures_total",
+		Help:      "Number of failed cache refreshes.",
+	}, []string{"cache"})
+
+	AggregatorRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "aggregator",
+		Name:      "runs_total",
+		Help:      "Number of daily and hourly stats aggregation runs by result.",
+	}, []string{"stats", "result"})
+
+	AggregatorLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "aggregator",
+		Name:      "leader",
+		Help:      "1 if this instance holds the aggregator lock, 0 otherwise.",
+	})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "code"})
+)
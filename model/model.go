@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,6 +21,18 @@ type Stats struct {
 	ProofsVerified  uint64    `json:"proofs_verified" db:"proofs_verified"`
 }
 
+// HourlyStats is throughput and latency of runs within an hour.
+type HourlyStats struct {
+	Hour          time.Time `json:"hour"`
+	Runs          uint64    `json:"runs"`
+	Proofs        uint64    `json:"proofs"`
+	Verifications uint64    `json:"verifications"`
+	// Latencies are percentiles of time from run submission to its completion
+	// for runs completed within the hour.
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP95 time.Duration `json:"latency_p95"`
+}
+
 type DeltaStats struct {
 	RegisteredUsers float64 `json:"registered_users_delta" db:"registered_users_delta"`
 	ProofsGenerated float64 `json:"proofs_generated_delta" db:"proofs_generated_delta"`
@@ -34,18 +48,97 @@ type CombinedStats struct {
 type Event struct {
 	State     State     `json:"state"`
 	TxID      string    `db:"tx_id" json:"tx_id"`
+	Kind      TxKind    `json:"kind,omitempty"`
+	Author    string    `json:"author,omitempty"`
 	ProverID  string    `db:"prover_id" json:"prover_id"`
 	Tag       string    `json:"tag"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
 type TxInfo struct {
-	State    State         `json:"state"`
-	Duration time.Duration `json:"duration"`
-	TxID     string        `json:"tx_id"`
-	UserID   string        `json:"user_id"`
-	ProverID string        `json:"prover_id"`
-	Log      []TxLogEvent  `json:"log"`
+	State State `json:"state"`
+	// Kind is the kind of the requested transaction. Workflow transactions
+	// are shown as part of their run, other kinds on their own.
+	Kind     TxKind         `json:"kind"`
+	Duration time.Duration  `json:"duration"`
+	TxID     string         `json:"tx_id"`
+	UserID   string         `json:"user_id"`
+	ProverID string         `json:"prover_id"`
+	Log      []TxLogEvent   `json:"log"`
+	Workflow []WorkflowStep `json:"workflow"`
+	// Deployment is set for deploy transactions.
+	Deployment *Deployment `json:"deployment,omitempty"`
+	// Files are attached to the transactions of the run.
+	Files []TxFile `json:"files"`
+	// Payloads are proofs, verifications and proof keys of the run.
+	Payloads []Payload `json:"payloads"`
+}
+
+// Deployment describes prover and verifier programs deployed by a deploy transaction.
+type Deployment struct {
+	Name     string `json:"name"`
+	Prover   string `json:"prover"`
+	Verifier string `json:"verifier"`
+}
+
+// WorkflowStep is a program run as a step of the run's workflow.
+type WorkflowStep struct {
+	Sequence    int          `json:"sequence"`
+	Program     string       `json:"program"`
+	ProgramName string       `json:"program_name"`
+	Args        []string     `json:"args"`
+	Inputs      []InputFile  `json:"inputs"`
+	Outputs     []OutputFile `json:"outputs"`
+}
+
+// InputFile is a file given to a workflow step.
+type InputFile struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
+}
+
+// OutputFile is a file produced by a workflow step.
+type OutputFile struct {
+	Name          string `json:"name"`
+	SourceProgram string `db:"source_program" json:"source_program"`
+}
+
+// TxFile is a file attached to a transaction.
+type TxFile struct {
+	TxID     string `db:"tx_id" json:"tx_id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
+}
+
+// Payload kinds.
+const (
+	PayloadProof        = "proof"
+	PayloadVerification = "verification"
+	PayloadProofKey     = "proof_key"
+)
+
+// Payload describes binary payload of a proof, verification or proof key transaction.
+type Payload struct {
+	TxID   string `db:"tx_id" json:"tx_id"`
+	Kind   string `json:"kind"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// PayloadPreview is a range of payload bytes encoded as text.
+type PayloadPreview struct {
+	Payload
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	Encoding string `json:"encoding"`
+	Data     string `json:"data"`
+}
+
+// More reports whether there are payload bytes after the previewed range.
+func (p PayloadPreview) More() bool {
+	return p.Offset+p.Length < p.Size
 }
 
 type TxLogEvent struct {
@@ -55,9 +148,216 @@ type TxLogEvent struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// SearchResult groups search matches by what was found.
+type SearchResult struct {
+	Programs     []ProgramMatch `json:"programs"`
+	Accounts     []AccountMatch `json:"accounts"`
+	Transactions []Event        `json:"transactions"`
+}
+
+// ProgramMatch is a program found by its hash or name.
+type ProgramMatch struct {
+	Hash string `json:"hash"`
+	Name string `json:"name"`
+}
+
+// AccountMatch is a transaction author found by its key.
+type AccountMatch struct {
+	Key          string    `json:"key"`
+	Transactions uint64    `json:"transactions"`
+	LastSeen     time.Time `db:"last_seen" json:"last_seen"`
+}
+
+// Program is a deployed program with its resource requirements.
+type Program struct {
+	Hash              string `json:"hash"`
+	Name              string `json:"name"`
+	ImageFileName     string `db:"image_file_name" json:"image_file_name"`
+	ImageFileURL      string `db:"image_file_url" json:"image_file_url"`
+	ImageFileChecksum string `db:"image_file_checksum" json:"image_file_checksum"`
+	Memory            uint64 `json:"memory"`
+	CPUs              uint64 `json:"cpus"`
+	GPUs              uint64 `json:"gpus"`
+	DeployTx          string `db:"deploy_tx" json:"deploy_tx"`
+	Prover            bool   `json:"prover"`
+	Verifier          bool   `json:"verifier"`
+}
+
+// ProgramStats describes how runs using a program have succeeded.
+type ProgramStats struct {
+	Runs      uint64 `json:"runs"`
+	Completed uint64 `json:"completed"`
+	// SuccessRate is the percentage of runs that have completed.
+	SuccessRate float64 `json:"success_rate"`
+	// AvgLatency is the average duration of completed runs.
+	AvgLatency time.Duration `json:"avg_latency"`
+}
+
+// ProgramInfo is a program with statistics and most recent runs using it.
+type ProgramInfo struct {
+	Program
+	Stats      ProgramStats `json:"stats"`
+	RecentRuns []Event      `json:"recent_runs"`
+}
+
+// Account is a key that has authored transactions.
+type Account struct {
+	Key         string    `json:"key"`
+	Whitelisted bool      `json:"whitelisted"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	// Transactions is the number of transactions authored by the key by kind.
+	// Proofs and verifications are authored by the node producing them.
+	Transactions map[string]uint64 `json:"transactions"`
+}
+
+// AccountInfo is an account with its most recent transactions.
+type AccountInfo struct {
+	Account
+	RecentTransactions []Event `json:"recent_transactions"`
+}
+
+// NodeRank is a node's entry in the leaderboard of nodes producing proofs
+// and verifications.
+type NodeRank struct {
+	Node          string `json:"node"`
+	Proofs        uint64 `json:"proofs"`
+	Verifications uint64 `json:"verifications"`
+	// MedianLatency is the median time from run submission to the node's
+	// proof or verification of it.
+	MedianLatency time.Duration `json:"median_latency"`
+	// Share is the percentage of all proofs and verifications done by the node.
+	Share float64 `json:"share"`
+}
+
+// TxFilter selects transactions to list. Zero valued fields match all transactions.
+type TxFilter struct {
+	// Text is matched against transaction, prover and author hashes and
+	// case-insensitively against tag. If TextPrefix is true, hashes starting
+	// with Text match. Prover and Author are matched the same way.
+	Text         string
+	TextPrefix   bool
+	State        State
+	Kind         TxKind
+	Prover       string
+	ProverPrefix bool
+	Author       string
+	AuthorPrefix bool
+	Tag          string
+	Since        time.Time
+	Until        time.Time
+}
+
+// TxCursor points to a transaction in the list of transactions ordered by
+// creation time and hash.
+type TxCursor struct {
+	Timestamp time.Time
+	TxID      string
+}
+
+// CursorOf returns cursor pointing to the event.
+func CursorOf(e Event) TxCursor {
+	return TxCursor{Timestamp: e.Timestamp, TxID: e.TxID}
+}
+
+func (c TxCursor) IsZero() bool {
+	return c.TxID == "" && c.Timestamp.IsZero()
+}
+
+// String encodes cursor for use in URLs.
+func (c TxCursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d_%s", c.Timestamp.UnixMicro(), c.TxID)
+}
+
+// ParseTxCursor parses cursor encoded with TxCursor.String.
+func ParseTxCursor(s string) (TxCursor, error) {
+	if s == "" {
+		return TxCursor{}, nil
+	}
+
+	ts, id, ok := strings.Cut(s, "_")
+	if !ok || id == "" {
+		return TxCursor{}, fmt.Errorf("invalid cursor: %q", s)
+	}
+
+	micros, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return TxCursor{}, fmt.Errorf("invalid cursor: %q", s)
+	}
+	return TxCursor{Timestamp: time.UnixMicro(micros), TxID: id}, nil
+}
+
+// Less reports whether c is before other in creation order.
+func (c TxCursor) Less(other TxCursor) bool {
+	if !c.Timestamp.Equal(other.Timestamp) {
+		return c.Timestamp.Before(other.Timestamp)
+	}
+	return c.TxID < other.TxID
+}
+
+// Page selects a page of transactions. If Before is set, transactions older than
+// it are returned. If After is set, transactions newer than it are returned.
+// Otherwise the page starts from the newest transaction.
+type Page struct {
+	Before TxCursor
+	After  TxCursor
+	Limit  int
+}
+
+// TxPage is a page of transactions in newest first order with cursors
+// for navigating to older and newer pages. Cursor is empty if there is no
+// page in that direction.
+type TxPage struct {
+	Events []Event `json:"events"`
+	Older  string  `json:"older,omitempty"`
+	Newer  string  `json:"newer,omitempty"`
+}
+
+// NewTxPage builds a page from events fetched for p. Up to p.Limit+1 events
+// should be fetched so that it's known if there are more events after the page.
+// Events must be in newest first order, or oldest first if p.After is set.
+func NewTxPage(events []Event, p Page) TxPage {
+	more := len(events) > p.Limit
+	if more {
+		events = events[:p.Limit]
+	}
+
+	if !p.After.IsZero() {
+		slices.Reverse(events)
+	}
+
+	page := TxPage{Events: events}
+	if len(events) == 0 {
+		return page
+	}
+
+	newest, oldest := CursorOf(events[0]), CursorOf(events[len(events)-1])
+	switch {
+	case !p.After.IsZero():
+		page.Older = oldest.String()
+		if more {
+			page.Newer = newest.String()
+		}
+	case !p.Before.IsZero():
+		page.Newer = newest.String()
+		if more {
+			page.Older = oldest.String()
+		}
+	case more:
+		page.Older = oldest.String()
+	}
+	return page
+}
+
+// StatsRange is the time range stats are shown for. Ranges end now unless
+// Until returns non-zero time.
 type StatsRange interface {
 	String() string
 	Since() time.Time
+	Until() time.Time
 	sr()
 }
 
@@ -73,6 +373,10 @@ func (s sr) String() string {
 		return "6m"
 	case RangeYear:
 		return "1y"
+	case RangeYearToDate:
+		return "ytd"
+	case RangeAll:
+		return "all"
 	default:
 		return ""
 	}
@@ -88,37 +392,204 @@ func (s sr) Since() time.Time {
 		return time.Now().AddDate(0, -6, 0)
 	case RangeYear:
 		return time.Now().AddDate(-1, 0, 0)
+	case RangeYearToDate:
+		return time.Date(time.Now().UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
 	default:
 		return time.Time{}
 	}
 }
 
+func (s sr) Until() time.Time { return time.Time{} }
+
 func (s sr) sr() {}
 
 const (
-	RangeWeek     sr = 0
-	RangeMonth    sr = 1
-	RangeHalfYear sr = 2
-	RangeYear     sr = 3
+	RangeWeek       sr = 0
+	RangeMonth      sr = 1
+	RangeHalfYear   sr = 2
+	RangeYear       sr = 3
+	RangeYearToDate sr = 4
+	RangeAll        sr = 5
 )
 
+// relativeRange is a range of n days, weeks, months or years ending now.
+type relativeRange struct {
+	n    int
+	unit byte
+}
+
+func (r relativeRange) String() string {
+	return strconv.Itoa(r.n) + string(r.unit)
+}
+
+func (r relativeRange) Since() time.Time {
+	switch r.unit {
+	case 'd':
+		return time.Now().AddDate(0, 0, -r.n)
+	case 'w':
+		return time.Now().AddDate(0, 0, -7*r.n)
+	case 'm':
+		return time.Now().AddDate(0, -r.n, 0)
+	default:
+		return time.Now().AddDate(-r.n, 0, 0)
+	}
+}
+
+func (r relativeRange) Until() time.Time { return time.Time{} }
+
+func (r relativeRange) sr() {}
+
+// timeRange is a range between fixed times. Zero to means the range ends now.
+type timeRange struct {
+	from, to time.Time
+}
+
+func (r timeRange) String() string {
+	return formatRangeTime(r.from) + ".." + formatRangeTime(r.to)
+}
+
+func (r timeRange) Since() time.Time { return r.from }
+
+func (r timeRange) Until() time.Time { return r.to }
+
+func (r timeRange) sr() {}
+
+func formatRangeTime(t time.Time) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case t.Equal(t.Truncate(24 * time.Hour)):
+		return t.Format(time.DateOnly)
+	default:
+		return t.Format(time.RFC3339)
+	}
+}
+
+// maxRelativeRange limits the count of relative ranges, so that absurd ranges
+// like 1000000y are rejected instead of overflowing dates.
+const maxRelativeRange = 1000
+
+// ParseStatsRange parses predefined ranges (1w, 1m, 6m, 1y, ytd and all),
+// relative ranges of days, weeks, months or years like 3d and ranges between
+// times or dates like 2024-03-01..2024-04-01, where end is exclusive and
+// optional.
 func ParseStatsRange(r string) (StatsRange, error) {
-	switch strings.ToUpper(r) {
-	case "1W":
+	r = strings.ToLower(r)
+	switch r {
+	case "1w":
 		return RangeWeek, nil
-	case "1M":
+	case "1m":
 		return RangeMonth, nil
-	case "6M":
+	case "6m":
 		return RangeHalfYear, nil
-	case "1Y":
+	case "1y":
 		return RangeYear, nil
+	case "ytd":
+		return RangeYearToDate, nil
+	case "all":
+		return RangeAll, nil
+	}
+
+	if from, to, ok := strings.Cut(r, ".."); ok {
+		return ParseStatsTimeRange(from, to)
+	}
+
+	if len(r) >= 2 && strings.IndexByte("dwmy", r[len(r)-1]) >= 0 {
+		n, err := strconv.Atoi(r[:len(r)-1])
+		if err == nil && n > 0 && n <= maxRelativeRange {
+			return relativeRange{n: n, unit: r[len(r)-1]}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid StatsRange string")
+}
+
+// ParseStatsTimeRange parses range between from and to, which are RFC3339
+// timestamps or dates. Empty to means the range ends now.
+func ParseStatsTimeRange(from, to string) (StatsRange, error) {
+	if from == "" {
+		return nil, errors.New("range start is required")
+	}
+
+	f, err := ParseTime(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range start: %w", err)
+	}
+
+	t, err := ParseTime(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range end: %w", err)
+	}
+
+	if !t.IsZero() && !f.Before(t) {
+		return nil, errors.New("range start must be before range end")
+	}
+	return timeRange{from: f.UTC(), to: t.UTC()}, nil
+}
+
+// PredefinedStatsRanges returns every predefined range, which are precomputed.
+func PredefinedStatsRanges() []StatsRange {
+	return []StatsRange{RangeWeek, RangeMonth, RangeHalfYear, RangeYear, RangeYearToDate, RangeAll}
+}
+
+// StatsBucket is the time bucket stats series is downsampled to. Buckets are
+// named after Postgres date_trunc fields.
+type StatsBucket string
+
+const (
+	BucketDay   StatsBucket = "day"
+	BucketWeek  StatsBucket = "week"
+	BucketMonth StatsBucket = "month"
+)
+
+func ParseStatsBucket(s string) (StatsBucket, error) {
+	switch b := StatsBucket(strings.ToLower(s)); b {
+	case BucketDay, BucketWeek, BucketMonth:
+		return b, nil
 	default:
-		return nil, fmt.Errorf("invalid StatsRange string")
+		return "", fmt.Errorf("invalid stats bucket: %s", s)
+	}
+}
+
+// TxKind is kind of a Gevulot transaction as stored by the node.
+type TxKind string
+
+const (
+	KindEmpty        TxKind = "empty"
+	KindTransfer     TxKind = "transfer"
+	KindStake        TxKind = "stake"
+	KindUnstake      TxKind = "unstake"
+	KindDeploy       TxKind = "deploy"
+	KindRun          TxKind = "run"
+	KindProof        TxKind = "proof"
+	KindProofKey     TxKind = "proofkey"
+	KindVerification TxKind = "verification"
+	KindCancel       TxKind = "cancel"
+)
+
+// TxKinds returns all transaction kinds.
+func TxKinds() []TxKind {
+	return []TxKind{
+		KindEmpty, KindTransfer, KindStake, KindUnstake, KindDeploy,
+		KindRun, KindProof, KindProofKey, KindVerification, KindCancel,
+	}
+}
+
+func ParseTxKind(s string) (TxKind, error) {
+	k := TxKind(strings.ToLower(s))
+	if !slices.Contains(TxKinds(), k) {
+		return "", fmt.Errorf("invalid transaction kind: %s", s)
 	}
+	return k, nil
 }
 
-func SupportedStatsRanges() []StatsRange {
-	return []StatsRange{RangeWeek, RangeMonth, RangeHalfYear, RangeYear}
+// IsWorkflow reports whether transactions of the kind belong to a run.
+func (k TxKind) IsWorkflow() bool {
+	switch k {
+	case KindRun, KindProof, KindProofKey, KindVerification:
+		return true
+	default:
+		return false
+	}
 }
 
 type State uint8
@@ -163,7 +634,7 @@ func (s *State) Value() (driver.Value, error) {
 	return s.String(), nil
 }
 
-func (s *State) MarshalJSON() ([]byte, error) {
+func (s State) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s.String())
 }
 
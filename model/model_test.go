@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatsRange(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		in    string
+		want  string
+		since time.Time
+		until time.Time
+	}{
+		{in: "1W", want: "1w", since: now.AddDate(0, 0, -7)},
+		{in: "6m", want: "6m", since: now.AddDate(0, -6, 0)},
+		{in: "3d", want: "3d", since: now.AddDate(0, 0, -3)},
+		{in: "2w", want: "2w", since: now.AddDate(0, 0, -14)},
+		{in: "18m", want: "18m", since: now.AddDate(0, -18, 0)},
+		{in: "2y", want: "2y", since: now.AddDate(-2, 0, 0)},
+		{in: "ytd", want: "ytd", since: time.Date(now.UTC().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "all", want: "all"},
+		{
+			in:    "2024-03-01..2024-04-01",
+			want:  "2024-03-01..2024-04-01",
+			since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			until: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			in:    "2024-03-01T12:00:00Z..",
+			want:  "2024-03-01T12:00:00Z..",
+			since: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := ParseStatsRange(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, r.String())
+			assert.WithinDuration(t, tt.since, r.Since(), time.Second)
+			assert.Equal(t, tt.until, r.Until())
+		})
+	}
+
+	for _, in := range []string{"", "2x", "0d", "-1w", "d", "1001y", "..", "2024-04-01..2024-03-01", "2024-03-01..later"} {
+		_, err := ParseStatsRange(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestPredefinedStatsRanges(t *testing.T) {
+	// Predefined ranges parse to the same values, so they are served from
+	// the precomputed stats.
+	for _, r := range PredefinedStatsRanges() {
+		got, err := ParseStatsRange(r.String())
+		require.NoError(t, err)
+		assert.Equal(t, r, got)
+	}
+}
+
+func TestStateJSON(t *testing.T) {
+	// State is marshaled by name also when it isn't addressable.
+	data, err := json.Marshal(TxInfo{State: StateProving})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"state":"proving"`)
+
+	var info TxInfo
+	require.NoError(t, json.Unmarshal(data, &info))
+	assert.Equal(t, StateProving, info.State)
+}
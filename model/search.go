@@ -0,0 +1,164 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ParseSearchQuery parses search query into a filter. Query consists of space
+// separated terms where terms in 'key:value' form set the filter field of the key
+// and a term without a key is used as free text search.
+//
+// Supported keys are state, kind, prover, author, tag, after and before. Times
+// are either dates (2024-03-01) or RFC3339 timestamps.
+//
+// Free text, prover and author of at least minPrefixLen characters are matched
+// as hash prefixes, shorter ones have to match exactly. Keys, free text and
+// hashes are case insensitive.
+func ParseSearchQuery(q string, minPrefixLen int) (TxFilter, error) {
+	var f TxFilter
+	for _, term := range strings.Fields(q) {
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			if f.Text != "" {
+				return TxFilter{}, fmt.Errorf("only one free text term allowed, got %q and %q", f.Text, term)
+			}
+			f.Text = strings.ToLower(term)
+			f.TextPrefix = len(term) >= minPrefixLen
+			continue
+		}
+
+		if value == "" {
+			return TxFilter{}, fmt.Errorf("missing value for %q", key)
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "state":
+			f.State, err = ParseState(value)
+		case "kind":
+			f.Kind, err = ParseTxKind(value)
+		case "prover":
+			f.Prover, f.ProverPrefix = strings.ToLower(value), len(value) >= minPrefixLen
+		case "author":
+			f.Author, f.AuthorPrefix = strings.ToLower(value), len(value) >= minPrefixLen
+		case "tag":
+			f.Tag = value
+		case "after":
+			f.Since, err = ParseTime(value)
+		case "before":
+			f.Until, err = ParseTime(value)
+		default:
+			err = fmt.Errorf("unknown search key %q", key)
+		}
+
+		if err != nil {
+			return TxFilter{}, err
+		}
+	}
+	return f, nil
+}
+
+// ParseTime parses time either as RFC3339 timestamp or as a date. Letters of
+// the timestamp are case insensitive.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, strings.ToUpper(s))
+}
+
+// Streamable reports whether live events can match the filter. Live events
+// are state updates of runs, so other transaction kinds never match.
+func (f TxFilter) Streamable() bool {
+	return f.Kind == "" || f.Kind == KindRun
+}
+
+// Match reports whether the event matches the filter. Events without kind are
+// live run events, so they are matched as runs. Hashes are matched like in the
+// store, filter hashes are expected to be lowercase.
+func (f TxFilter) Match(e Event) bool {
+	kind := e.Kind
+	if kind == "" {
+		kind = KindRun
+	}
+
+	return (f.Text == "" || f.matchText(e)) &&
+		(f.State == StateUnknown || e.State == f.State) &&
+		(f.Kind == "" || kind == f.Kind) &&
+		(f.Prover == "" || matchHash(e.ProverID, f.Prover, f.ProverPrefix)) &&
+		(f.Author == "" || matchHash(e.Author, f.Author, f.AuthorPrefix)) &&
+		(f.Tag == "" || strings.EqualFold(e.Tag, f.Tag)) &&
+		(f.Since.IsZero() || !e.Timestamp.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Timestamp.Before(f.Until))
+}
+
+// matchText matches free text against transaction, prover and author hashes
+// and program name in tag. Store implementations must use the same semantics.
+func (f TxFilter) matchText(e Event) bool {
+	return f.MatchHash(e.TxID) || f.MatchHash(e.ProverID) || f.MatchHash(e.Author) || f.MatchName(e.Tag)
+}
+
+// MatchHash reports whether the hash matches free text of the filter.
+func (f TxFilter) MatchHash(h string) bool {
+	return matchHash(h, f.Text, f.TextPrefix)
+}
+
+// matchHash reports whether the hash is the value, or starts with it if
+// prefix is true.
+func matchHash(h, value string, prefix bool) bool {
+	if prefix {
+		return strings.HasPrefix(h, value)
+	}
+	return h == value
+}
+
+// NameSimilarityThreshold is the trigram similarity at which names match free
+// text. It's the default pg_trgm.similarity_threshold used by the % operator.
+const NameSimilarityThreshold = 0.3
+
+// MatchName reports whether the name contains free text of the filter, ignoring
+// case, or is similar to it.
+func (f TxFilter) MatchName(name string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(f.Text)) ||
+		Similarity(name, f.Text) >= NameSimilarityThreshold
+}
+
+// Similarity returns trigram similarity of the strings like similarity() of
+// pg_trgm: the number of trigrams they share divided by the number of distinct
+// trigrams of both.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	if all := len(ta) + len(tb) - shared; all > 0 {
+		return float64(shared) / float64(all)
+	}
+	return 0
+}
+
+// trigrams returns the set of trigrams of words of s. Like in pg_trgm, words
+// are lowercase runs of letters and digits padded with two spaces in front and
+// one after.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    TxFilter
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			query: "",
+			want:  TxFilter{},
+		},
+		{
+			name:  "free text",
+			query: " abc ",
+			want:  TxFilter{Text: "abc"},
+		},
+		{
+			name:  "free text prefix",
+			query: "abcd",
+			want:  TxFilter{Text: "abcd", TextPrefix: true},
+		},
+		{
+			name:  "all keys",
+			query: "prover:abc state:Proving kind:Proof author:04ff tag:starknet after:2024-03-01 before:2024-03-02T12:00:00Z def",
+			want: TxFilter{
+				Text:         "def",
+				State:        StateProving,
+				Kind:         "proof",
+				Prover:       "abc",
+				Author:       "04ff",
+				AuthorPrefix: true,
+				Tag:          "starknet",
+				Since:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				Until:        time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "case insensitive",
+			query: "PROVER:ABC Author:04FF after:2024-03-01t10:00:00z ABCD",
+			want: TxFilter{
+				Text:         "abcd",
+				TextPrefix:   true,
+				Prover:       "abc",
+				Author:       "04ff",
+				AuthorPrefix: true,
+				Since:        time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "unknown key",
+			query:   "foo:bar",
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			query:   "prover:",
+			wantErr: true,
+		},
+		{
+			name:    "invalid state",
+			query:   "state:foo",
+			wantErr: true,
+		},
+		{
+			name:  "proof key kind",
+			query: "kind:proofkey",
+			want:  TxFilter{Kind: KindProofKey},
+		},
+		{
+			name:    "invalid kind",
+			query:   "kind:foo",
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			query:   "after:yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "multiple free text terms",
+			query:   "abc def",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(tt.query, 4)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTxFilterMatch(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := Event{
+		State:     StateProving,
+		TxID:      "abcdef",
+		Author:    "04ff",
+		ProverID:  "123456",
+		Tag:       "starknet",
+		Timestamp: ts,
+	}
+
+	tests := []struct {
+		name   string
+		filter TxFilter
+		want   bool
+	}{
+		{name: "empty", filter: TxFilter{}, want: true},
+		{name: "exact tx id", filter: TxFilter{Text: "abcdef"}, want: true},
+		{name: "tx id prefix", filter: TxFilter{Text: "abcd", TextPrefix: true}, want: true},
+		{name: "tx id prefix without prefix matching", filter: TxFilter{Text: "abcd"}, want: false},
+		{name: "tx id infix", filter: TxFilter{Text: "bcde", TextPrefix: true}, want: false},
+		{name: "prover prefix", filter: TxFilter{Text: "1234", TextPrefix: true}, want: true},
+		{name: "author prefix", filter: TxFilter{Text: "04", TextPrefix: true}, want: true},
+		{name: "tag", filter: TxFilter{Text: "starknet"}, want: true},
+		{name: "tag partial and case-insensitive", filter: TxFilter{Text: "STARK"}, want: true},
+		{name: "tag similar", filter: TxFilter{Text: "starknt"}, want: true},
+		{name: "tag dissimilar", filter: TxFilter{Text: "strakne"}, want: false},
+		{name: "text mismatch", filter: TxFilter{Text: "xyz", TextPrefix: true}, want: false},
+		{name: "state", filter: TxFilter{State: StateProving}, want: true},
+		{name: "state mismatch", filter: TxFilter{State: StateComplete}, want: false},
+		{name: "kind defaults to run", filter: TxFilter{Kind: "run"}, want: true},
+		{name: "kind mismatch", filter: TxFilter{Kind: "proof"}, want: false},
+		{name: "prover", filter: TxFilter{Prover: "123456"}, want: true},
+		{name: "prover partial", filter: TxFilter{Prover: "123"}, want: false},
+		{name: "prover prefix key", filter: TxFilter{Prover: "1234", ProverPrefix: true}, want: true},
+		{name: "prover infix key", filter: TxFilter{Prover: "2345", ProverPrefix: true}, want: false},
+		{name: "author", filter: TxFilter{Author: "04ff"}, want: true},
+		{name: "author prefix key", filter: TxFilter{Author: "04", AuthorPrefix: true}, want: true},
+		{name: "author matched exactly like in the store", filter: TxFilter{Author: "04FF"}, want: false},
+		{name: "tag", filter: TxFilter{Tag: "StarkNet"}, want: true},
+		{name: "since", filter: TxFilter{Since: ts}, want: true},
+		{name: "since mismatch", filter: TxFilter{Since: ts.Add(time.Second)}, want: false},
+		{name: "until", filter: TxFilter{Until: ts.Add(time.Second)}, want: true},
+		{name: "until mismatch", filter: TxFilter{Until: ts}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(e))
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "starknet", b: "starknet", want: 1},
+		{a: "StarkNet", b: "starknet", want: 1},
+		{a: "stark", b: "starknet", want: 0.5},
+		{a: "stark prover", b: "prover", want: 7.0 / 13},
+		{a: "starknet", b: "zkvm", want: 0},
+		{a: "", b: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, Similarity(tt.a, tt.b), 1e-9, "%q %q", tt.a, tt.b)
+	}
+}
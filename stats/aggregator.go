@@ -4,65 +4,220 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
 	"github.com/gevulotnetwork/devnet-explorer/model"
 )
 
+const DefaultHourlyRetention = 30 * 24 * time.Hour
+
 type Store interface {
-	LatestDailyStats() (model.Stats, error)
-	AggregateStats(time.Time) error
+	// MissingDailyStats returns days from the first day with stats or
+	// transactions up to the given day which have no stats, oldest first.
+	MissingDailyStats(until time.Time) ([]time.Time, error)
+	// AggregateStats stores stats of the day reconstructed from transaction
+	// creation times, replacing earlier stats of the day.
+	AggregateStats(day time.Time) error
+	LatestHourlyStats() (model.HourlyStats, error)
+	AggregateHourlyStats(hour time.Time) error
+	DeleteHourlyStats(before time.Time) error
+	// IsLeader reports whether this replica is the one aggregating stats.
+	IsLeader() (bool, error)
 }
 
 type Aggregator struct {
-	store Store
-	done  chan struct{}
+	store           Store
+	hourlyRetention time.Duration
+	running         atomic.Bool
+	done            chan struct{}
 }
 
-func NewAggregator(store Store) *Aggregator {
-	return &Aggregator{
-		store: store,
-		done:  make(chan struct{}),
-	}
+// Option configures optional Aggregator parameters.
+type Option func(*Aggregator)
+
+// WithHourlyRetention sets how long hourly stats are kept.
+func WithHourlyRetention(d time.Duration) Option {
+	return func(a *Aggregator) { a.hourlyRetention = d }
 }
 
-func (a *Aggregator) Run() error {
-	s, err := a.store.LatestDailyStats()
-	if err != nil && !errors.Is(err, model.ErrNotFound) {
-		return fmt.Errorf("failed to get latest aggregated stats: %w", err)
+func NewAggregator(store Store, opts ...Option) *Aggregator {
+	a := &Aggregator{
+		store:           store,
+		hourlyRetention: DefaultHourlyRetention,
+		done:            make(chan struct{}),
 	}
 
-	if errors.Is(err, model.ErrNotFound) {
-		slog.Info("no old stats found, next run will aggregate first stats")
+	for _, opt := range opts {
+		opt(a)
 	}
+	return a
+}
 
-	lastRan := s.CreatedAt
+// Run aggregates stats while this replica is the leader. Only one replica
+// aggregates at a time, others wait for the leader to go away.
+func (a *Aggregator) Run() error {
+	a.running.Store(true)
+	defer a.running.Store(false)
 
 	t := time.NewTicker(time.Minute)
 	defer t.Stop()
 
+	var caughtUp, lastHour time.Time
+	leader := false
 	for {
-		select {
-		case <-t.C:
-			now := time.Now()
-			if monotonicDay(now) > monotonicDay(lastRan) {
-				slog.Info("aggregating stats", slog.Time("last_ran", lastRan), slog.Time("now", now))
-				if err := a.store.AggregateStats(now); err != nil {
-					slog.Error("failed to aggregate stats", slog.String("error", err.Error()))
-					continue
-				}
-				lastRan = now
+		switch isLeader := a.isLeader(); {
+		case isLeader && !leader:
+			// Other replica may have aggregated stats while this one wasn't
+			// the leader, so aggregation continues from the latest stats.
+			var err error
+			if lastHour, err = a.latestHour(); err != nil {
+				slog.Error("failed to get latest hourly stats", slog.String("error", err.Error()))
+				break
 			}
+			slog.Info("became stats aggregator leader")
+			caughtUp = time.Time{}
+			leader = true
+		case !isLeader && leader:
+			slog.Info("lost stats aggregator leadership")
+			leader = false
+		}
 
+		if leader {
+			now := time.Now()
+			lastHour = a.aggregateHourly(now, lastHour)
+			caughtUp = a.catchUp(now, caughtUp)
+		}
+
+		select {
+		case <-t.C:
 		case <-a.done:
 			return nil
 		}
 	}
 }
 
-func monotonicDay(t time.Time) int64 {
-	const secsInDay = 24 * 60 * 60
-	return t.Unix() / secsInDay
+func (a *Aggregator) isLeader() bool {
+	leader, err := a.store.IsLeader()
+	if err != nil {
+		slog.Error("failed to check stats aggregator leadership", slog.String("error", err.Error()))
+		leader = false
+	}
+
+	if leader {
+		metrics.AggregatorLeader.Set(1)
+	} else {
+		metrics.AggregatorLeader.Set(0)
+	}
+	return leader
+}
+
+// latestHour returns the latest aggregated hour, hours after it are
+// aggregated next.
+func (a *Aggregator) latestHour() (time.Time, error) {
+	h, err := a.store.LatestHourlyStats()
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return time.Time{}, err
+	}
+	return h.Hour, nil
+}
+
+// aggregateHourly aggregates stats of each completed hour after lastHour
+// within the retention and deletes hourly stats older than the retention. It
+// returns the latest aggregated hour. Hours are aggregated in order and
+// aggregation stops at the first failure, so failed hour is retried on the
+// next run.
+func (a *Aggregator) aggregateHourly(now, lastHour time.Time) time.Time {
+	oldest := now.Add(-a.hourlyRetention)
+	first := oldest.Truncate(time.Hour)
+	if first.Before(oldest) {
+		first = first.Add(time.Hour)
+	}
+	if next := lastHour.Add(time.Hour); next.After(first) {
+		first = next
+	}
+
+	last := now.Truncate(time.Hour).Add(-time.Hour)
+	if first.After(last) {
+		return lastHour
+	}
+
+	for hour := first; !hour.After(last); hour = hour.Add(time.Hour) {
+		slog.Info("aggregating hourly stats", slog.Time("hour", hour))
+		if err := a.store.AggregateHourlyStats(hour); err != nil {
+			slog.Error("failed to aggregate hourly stats", slog.String("error", err.Error()))
+			metrics.AggregatorRuns.WithLabelValues("hourly", "failure").Inc()
+			return lastHour
+		}
+		metrics.AggregatorRuns.WithLabelValues("hourly", "success").Inc()
+		lastHour = hour
+	}
+
+	if err := a.store.DeleteHourlyStats(oldest); err != nil {
+		slog.Error("failed to delete old hourly stats", slog.String("error", err.Error()))
+	}
+	return lastHour
+}
+
+// catchUp aggregates stats of each completed day without stats, including
+// days missed during downtime and gaps left by failed aggregations, unless
+// days up to caughtUp are already known to have stats. It returns the last day
+// known to have stats. Days are aggregated in order and aggregation stops at
+// the first failure, so failed day is retried on the next run.
+func (a *Aggregator) catchUp(now, caughtUp time.Time) time.Time {
+	yesterday := day(now).AddDate(0, 0, -1)
+	if !yesterday.After(caughtUp) {
+		return caughtUp
+	}
+
+	days, err := a.store.MissingDailyStats(yesterday)
+	if err != nil {
+		slog.Error("failed to find days without stats", slog.String("error", err.Error()))
+		return caughtUp
+	}
+
+	for _, d := range days {
+		if err := aggregateDay(a.store, d); err != nil {
+			slog.Error("failed to aggregate stats", slog.String("error", err.Error()))
+			return caughtUp
+		}
+	}
+	return yesterday
+}
+
+// Backfill aggregates stats of each day from the day of from to the day of
+// to, replacing existing stats of the days.
+func Backfill(s Store, from, to time.Time) error {
+	for d := day(from); !d.After(day(to)); d = d.AddDate(0, 0, 1) {
+		if err := aggregateDay(s, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func aggregateDay(s Store, d time.Time) error {
+	slog.Info("aggregating stats", slog.String("day", d.Format(time.DateOnly)))
+	if err := s.AggregateStats(d); err != nil {
+		metrics.AggregatorRuns.WithLabelValues("daily", "failure").Inc()
+		return fmt.Errorf("failed to aggregate stats of %s: %w", d.Format(time.DateOnly), err)
+	}
+	metrics.AggregatorRuns.WithLabelValues("daily", "success").Inc()
+	return nil
+}
+
+// day returns start of the UTC day of t.
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// Ready returns an error if aggregator is not running.
+func (a *Aggregator) Ready() error {
+	if !a.running.Load() {
+		return errors.New("aggregator not running")
+	}
+	return nil
 }
 
 func (a *Aggregator) Stop() error {
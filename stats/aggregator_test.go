@@ -1,18 +1,22 @@
 package stats
 
 import (
+	"errors"
 	"math/rand"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
 )
 
-func TestMonotonicDay(t *testing.T) {
+func TestDay(t *testing.T) {
 	now := time.Now()
 	prev := now.AddDate(0, 0, -1)
 
 	for i := 0; i < 1000; i++ {
-		if monotonicDay(now) <= monotonicDay(prev) {
-			t.Fatalf("monotonicDay(now) %q <= monotonicDay(prev) %q", now.Format(time.DateTime), prev.Format(time.DateTime))
+		if !day(now).After(day(prev)) {
+			t.Fatalf("day(now) %q <= day(prev) %q", now.Format(time.DateTime), prev.Format(time.DateTime))
 		}
 
 		// Update prev with current time.
@@ -25,3 +29,197 @@ func TestMonotonicDay(t *testing.T) {
 		now = now.Add(time.Minute * time.Duration(rand.Int63n(240)))
 	}
 }
+
+type fakeStore struct {
+	follower   bool
+	first      time.Time
+	days       []time.Time
+	dayErr     error
+	lookups    int
+	lastHour   time.Time
+	aggregated []time.Time
+	deleted    []time.Time
+	err        error
+}
+
+func (s *fakeStore) IsLeader() (bool, error) { return !s.follower, nil }
+
+// MissingDailyStats returns days from first to until which aren't in days.
+// First is the day of the earliest stats or transaction.
+func (s *fakeStore) MissingDailyStats(until time.Time) ([]time.Time, error) {
+	s.lookups++
+	first := s.first
+	if first.IsZero() {
+		first = until
+	}
+
+	var missing []time.Time
+	for d := first; !d.After(until); d = d.AddDate(0, 0, 1) {
+		if !slices.Contains(s.days, d) {
+			missing = append(missing, d)
+		}
+	}
+	return missing, nil
+}
+
+func (s *fakeStore) AggregateStats(d time.Time) error {
+	if s.dayErr != nil {
+		return s.dayErr
+	}
+	s.days = append(s.days, d)
+	return nil
+}
+
+func (s *fakeStore) LatestHourlyStats() (model.HourlyStats, error) {
+	return model.HourlyStats{Hour: s.lastHour}, nil
+}
+
+func (s *fakeStore) DeleteHourlyStats(t time.Time) error {
+	s.deleted = append(s.deleted, t)
+	return nil
+}
+
+func (s *fakeStore) AggregateHourlyStats(t time.Time) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.aggregated = append(s.aggregated, t)
+	return nil
+}
+
+func TestAggregateHourly(t *testing.T) {
+	s := &fakeStore{}
+	a := NewAggregator(s, WithHourlyRetention(3*time.Hour))
+
+	// Without earlier stats every hour within the retention is aggregated.
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	prev := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)
+	last := a.aggregateHourly(now, time.Time{})
+	if !last.Equal(prev) {
+		t.Fatalf("last aggregated hour %s, want %s", last, prev)
+	}
+	want := []time.Time{prev.Add(-time.Hour), prev}
+	if !slices.Equal(s.aggregated, want) {
+		t.Fatalf("aggregated hours %v, want %v", s.aggregated, want)
+	}
+	if len(s.deleted) != 1 || !s.deleted[0].Equal(now.Add(-3*time.Hour)) {
+		t.Fatalf("deleted before %v, want %s", s.deleted, now.Add(-3*time.Hour))
+	}
+
+	// Same hour isn't aggregated twice.
+	if last = a.aggregateHourly(now.Add(20*time.Minute), last); len(s.aggregated) != 2 {
+		t.Fatalf("hour aggregated again: %v", s.aggregated)
+	}
+
+	// Hours missed during downtime are filled in.
+	s.aggregated = nil
+	if last = a.aggregateHourly(now.Add(2*time.Hour), last); !last.Equal(prev.Add(2 * time.Hour)) {
+		t.Fatalf("last aggregated hour %s, want %s", last, prev.Add(2*time.Hour))
+	}
+	want = []time.Time{prev.Add(time.Hour), prev.Add(2 * time.Hour)}
+	if !slices.Equal(s.aggregated, want) {
+		t.Fatalf("aggregated hours %v, want %v", s.aggregated, want)
+	}
+
+	// Failed hour is retried on next run.
+	s.err = errors.New("db down")
+	if got := a.aggregateHourly(now.Add(4*time.Hour), last); !got.Equal(last) {
+		t.Fatalf("last aggregated hour %s after failure, want %s", got, last)
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	first := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &fakeStore{first: first, days: []time.Time{first, first.AddDate(0, 0, 2)}}
+	a := NewAggregator(s)
+
+	// Gaps within earlier stats and days since the latest stats are filled in.
+	now := time.Date(2024, 3, 5, 0, 30, 0, 0, time.UTC)
+	caughtUp := a.catchUp(now, time.Time{})
+	want := []time.Time{first, first.AddDate(0, 0, 2), first.AddDate(0, 0, 1), first.AddDate(0, 0, 3)}
+	if !slices.Equal(s.days, want) {
+		t.Fatalf("aggregated days %v, want %v", s.days, want)
+	}
+	if !caughtUp.Equal(want[3]) {
+		t.Fatalf("caught up to %s, want %s", caughtUp, want[3])
+	}
+
+	// Current day is aggregated only after it has ended, so nothing is looked
+	// up again before that.
+	if caughtUp = a.catchUp(now.Add(23*time.Hour), caughtUp); len(s.days) != 4 || s.lookups != 1 {
+		t.Fatalf("current day aggregated: %v", s.days)
+	}
+
+	// Failed day is retried on next run.
+	s.dayErr = errors.New("db down")
+	if got := a.catchUp(now.AddDate(0, 0, 2), caughtUp); !got.Equal(caughtUp) {
+		t.Fatalf("caught up to %s after failure, want %s", got, caughtUp)
+	}
+}
+
+func TestCatchUpWithoutStats(t *testing.T) {
+	// Every day since the first transaction is aggregated when no stats have
+	// been stored yet.
+	first := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &fakeStore{first: first}
+	a := NewAggregator(s)
+
+	caughtUp := a.catchUp(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC), time.Time{})
+	want := []time.Time{first, first.AddDate(0, 0, 1), first.AddDate(0, 0, 2)}
+	if !slices.Equal(s.days, want) {
+		t.Fatalf("aggregated days %v, want %v", s.days, want)
+	}
+	if !caughtUp.Equal(want[2]) {
+		t.Fatalf("caught up to %s, want %s", caughtUp, want[2])
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	s := &fakeStore{}
+	from := time.Date(2024, 2, 28, 15, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	if err := Backfill(s, from, to); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []time.Time{
+		time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !slices.Equal(s.days, want) {
+		t.Fatalf("aggregated days %v, want %v", s.days, want)
+	}
+
+	s.dayErr = errors.New("db down")
+	if err := Backfill(s, from, to); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRunLeader(t *testing.T) {
+	for _, follower := range []bool{false, true} {
+		s := &fakeStore{follower: follower, lastHour: time.Now().Truncate(time.Hour).Add(-2 * time.Hour)}
+		a := NewAggregator(s)
+
+		done := make(chan error)
+		go func() { done <- a.Run() }()
+		time.Sleep(100 * time.Millisecond)
+		if err := a.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+
+		// Leader aggregates yesterday and the hour after the latest one right
+		// away, others don't aggregate at all.
+		yesterday := day(time.Now()).AddDate(0, 0, -1)
+		switch {
+		case follower && (len(s.days) > 0 || len(s.aggregated) > 0):
+			t.Fatalf("follower aggregated days %v and hours %v", s.days, s.aggregated)
+		case !follower && (len(s.days) != 1 || !s.days[0].Equal(yesterday) || len(s.aggregated) != 1):
+			t.Fatalf("leader aggregated days %v and hours %v, want %s and last hour", s.days, s.aggregated, yesterday)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type LeaderboardStore interface {
+	Leaderboard(model.StatsRange) ([]model.NodeRank, error)
+}
+
+// LeaderboardCache periodically refreshes node leaderboards of every supported
+// stats range, same as Cache does for stats.
+type LeaderboardCache struct {
+	store       LeaderboardStore
+	interval    time.Duration
+	done        chan struct{}
+	leaderboard atomic.Value
+}
+
+type leaderboardMap map[model.StatsRange][]model.NodeRank
+
+func NewLeaderboardCache(s LeaderboardStore, interval time.Duration) *LeaderboardCache {
+	return &LeaderboardCache{
+		store:    s,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *LeaderboardCache) Run() error {
+	if err := s.refresh(); err != nil {
+		return fmt.Errorf("leaderboard cache initialization failed: %w", err)
+	}
+
+	t := time.NewTicker(s.interval)
+	for {
+		select {
+		case <-t.C:
+			if err := s.refresh(); err != nil {
+				slog.Error("leaderboard cache refresh failed", slog.String("error", err.Error()))
+			}
+		case <-s.done:
+			return nil
+		}
+	}
+}
+
+func (s *LeaderboardCache) Stop() error {
+	close(s.done)
+	return nil
+}
+
+func (s *LeaderboardCache) refresh() (err error) {
+	timer := prometheus.NewTimer(metrics.CacheRefreshDuration.WithLabelValues("leaderboard"))
+	defer func() {
+		timer.ObserveDuration()
+		if err != nil {
+			metrics.CacheRefreshFailures.WithLabelValues("leaderboard").Inc()
+		}
+	}()
+
+	leaderboardMap := make(leaderboardMap, len(model.PredefinedStatsRanges()))
+	for _, r := range model.PredefinedStatsRanges() {
+		nodes, err := s.store.Leaderboard(r)
+		if err != nil {
+			return fmt.Errorf("failed to get leaderboard for range %s: %w", r, err)
+		}
+		leaderboardMap[r] = nodes
+	}
+
+	s.leaderboard.Store(leaderboardMap)
+	slog.Info("Leaderboard cache updated")
+	return nil
+}
+
+// Ready returns an error until the first refresh has completed.
+func (s *LeaderboardCache) Ready() error {
+	if s.leaderboard.Load() == nil {
+		return errors.New("leaderboard cache not initialized")
+	}
+	return nil
+}
+
+// CachedLeaderboard returns the leaderboard of the range, or nil if the cache
+// is not initialized yet.
+func (s *LeaderboardCache) CachedLeaderboard(r model.StatsRange) []model.NodeRank {
+	m, _ := s.leaderboard.Load().(leaderboardMap)
+	return m[r]
+}
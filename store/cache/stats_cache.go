@@ -1,32 +1,68 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
 	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
 )
 
 type StatsStore interface {
 	Stats(model.StatsRange) (model.CombinedStats, error)
+	CurrentStats() (model.Stats, error)
+	RangeStats(model.Stats, model.StatsRange) (model.CombinedStats, error)
+	StatsSeries(model.StatsRange, model.StatsBucket) ([]model.Stats, error)
 }
 
+// maxCustomRanges limits the number of cached custom ranges. Ranges beyond the
+// limit are computed on each request until cached ranges expire.
+const maxCustomRanges = 100
+
 type Cache struct {
-	store    StatsStore
-	interval time.Duration
-	done     chan struct{}
-	stats    atomic.Value
+	store     StatsStore
+	interval  time.Duration
+	customTTL time.Duration
+	done      chan struct{}
+	stats     atomic.Value
+	series    atomic.Value
+
+	customMu sync.Mutex
+	custom   map[string]customStats
+	// computing deduplicates concurrent computations of the same custom range.
+	computing singleflight.Group
 }
 
 type statsMap map[model.StatsRange]model.CombinedStats
 
-func NewStatsCache(s StatsStore, interval time.Duration) *Cache {
+// seriesMap holds daily stats series of each range.
+type seriesMap map[model.StatsRange][]model.Stats
+
+// customStats holds stats of a range outside of predefined ranges, keyed by
+// the range string.
+type customStats struct {
+	stats   model.CombinedStats
+	series  []model.Stats
+	expires time.Time
+}
+
+// NewStatsCache returns cache which refreshes stats of predefined ranges every
+// interval. Stats of other ranges are computed on demand and kept for
+// customTTL.
+func NewStatsCache(s StatsStore, interval, customTTL time.Duration) *Cache {
 	return &Cache{
-		store:    s,
-		interval: interval,
-		done:     make(chan struct{}),
+		store:     s,
+		interval:  interval,
+		customTTL: customTTL,
+		done:      make(chan struct{}),
+		custom:    make(map[string]customStats),
 	}
 }
 
@@ -53,21 +89,144 @@ func (s *Cache) Stop() error {
 	return nil
 }
 
-func (s *Cache) refresh() error {
-	statsMap := make(statsMap, 4)
-	for _, r := range model.SupportedStatsRanges() {
-		stats, err := s.store.Stats(r)
+func (s *Cache) refresh() (err error) {
+	timer := prometheus.NewTimer(metrics.CacheRefreshDuration.WithLabelValues("stats"))
+	defer func() {
+		timer.ObserveDuration()
+		if err != nil {
+			metrics.CacheRefreshFailures.WithLabelValues("stats").Inc()
+		}
+	}()
+
+	// Predefined ranges end now, so they share current stats and their series
+	// are cut from the series of all stats.
+	current, err := s.store.CurrentStats()
+	if err != nil {
+		return fmt.Errorf("failed to get current stats: %w", err)
+	}
+
+	all, err := s.store.StatsSeries(model.RangeAll, model.BucketDay)
+	if err != nil {
+		return fmt.Errorf("failed to get stats series: %w", err)
+	}
+
+	ranges := model.PredefinedStatsRanges()
+	statsMap := make(statsMap, len(ranges))
+	seriesMap := make(seriesMap, len(ranges))
+	for _, r := range ranges {
+		stats, err := s.store.RangeStats(current, r)
 		if err != nil {
 			return fmt.Errorf("failed to get stats for range %s: %w", r, err)
 		}
 		statsMap[r] = stats
+		seriesMap[r] = seriesSince(all, r.Since())
 	}
 
+	s.series.Store(seriesMap)
 	s.stats.Store(statsMap)
 	slog.Info("Stats cache updated")
 	return nil
 }
 
-func (s *Cache) CachedStats(r model.StatsRange) model.CombinedStats {
-	return s.stats.Load().(statsMap)[r]
+// Ready returns an error until the first refresh has completed.
+func (s *Cache) Ready() error {
+	if s.stats.Load() == nil {
+		return errors.New("stats cache not initialized")
+	}
+	return nil
+}
+
+// CachedStats returns stats of the range. Stats of predefined ranges are zero
+// if cache isn't initialized yet.
+func (s *Cache) CachedStats(r model.StatsRange) (model.CombinedStats, error) {
+	if isPredefined(r) {
+		stats, _ := s.stats.Load().(statsMap)
+		return stats[r], nil
+	}
+
+	c, err := s.customStats(r)
+	return c.stats, err
+}
+
+// CachedStatsSeries returns daily stats series of the range. Series of
+// predefined ranges are nil if cache isn't initialized yet.
+func (s *Cache) CachedStatsSeries(r model.StatsRange) ([]model.Stats, error) {
+	if isPredefined(r) {
+		series, _ := s.series.Load().(seriesMap)
+		return series[r], nil
+	}
+
+	c, err := s.customStats(r)
+	return c.series, err
+}
+
+// customStats returns stats of a range outside of predefined ranges, computing
+// them if they aren't cached or have expired. Concurrent requests of the same
+// range share one computation.
+func (s *Cache) customStats(r model.StatsRange) (customStats, error) {
+	key := r.String()
+
+	s.customMu.Lock()
+	c, ok := s.custom[key]
+	s.customMu.Unlock()
+	if ok && time.Now().Before(c.expires) {
+		return c, nil
+	}
+
+	v, err, _ := s.computing.Do(key, func() (any, error) {
+		return s.computeCustomStats(r)
+	})
+	if err != nil {
+		return customStats{}, err
+	}
+	return v.(customStats), nil
+}
+
+func (s *Cache) computeCustomStats(r model.StatsRange) (customStats, error) {
+	key := r.String()
+	timer := prometheus.NewTimer(metrics.CacheRefreshDuration.WithLabelValues("custom_stats"))
+	defer timer.ObserveDuration()
+
+	stats, err := s.store.Stats(r)
+	if err != nil {
+		metrics.CacheRefreshFailures.WithLabelValues("custom_stats").Inc()
+		return customStats{}, fmt.Errorf("failed to get stats for range %s: %w", key, err)
+	}
+
+	series, err := s.store.StatsSeries(r, model.BucketDay)
+	if err != nil {
+		metrics.CacheRefreshFailures.WithLabelValues("custom_stats").Inc()
+		return customStats{}, fmt.Errorf("failed to get stats series for range %s: %w", key, err)
+	}
+
+	now := time.Now()
+	c := customStats{stats: stats, series: series, expires: now.Add(s.customTTL)}
+
+	s.customMu.Lock()
+	defer s.customMu.Unlock()
+	for k, v := range s.custom {
+		if !now.Before(v.expires) {
+			delete(s.custom, k)
+		}
+	}
+	if len(s.custom) < maxCustomRanges {
+		s.custom[key] = c
+	}
+	return c, nil
+}
+
+// seriesSince returns stats of the series, which is in oldest first order,
+// created after since.
+func seriesSince(series []model.Stats, since time.Time) []model.Stats {
+	i, _ := slices.BinarySearchFunc(series, since, func(s model.Stats, t time.Time) int {
+		if s.CreatedAt.After(t) {
+			return 1
+		}
+		return -1
+	})
+	return series[i:]
+}
+
+func isPredefined(r model.StatsRange) bool {
+	return slices.Contains(model.PredefinedStatsRanges(), r)
 }
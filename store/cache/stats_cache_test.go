@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gevulotnetwork/devnet-explorer/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	series  []model.Stats
+	current int
+	ranges  []model.StatsRange
+}
+
+func (s *fakeStore) Stats(model.StatsRange) (model.CombinedStats, error) {
+	panic("predefined ranges must share current stats")
+}
+
+func (s *fakeStore) CurrentStats() (model.Stats, error) {
+	s.current++
+	return model.Stats{ProofsGenerated: 42}, nil
+}
+
+func (s *fakeStore) RangeStats(stats model.Stats, r model.StatsRange) (model.CombinedStats, error) {
+	s.ranges = append(s.ranges, r)
+	return model.CombinedStats{Stats: stats}, nil
+}
+
+func (s *fakeStore) StatsSeries(model.StatsRange, model.StatsBucket) ([]model.Stats, error) {
+	return s.series, nil
+}
+
+func TestRefresh(t *testing.T) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	s := &fakeStore{series: []model.Stats{
+		{CreatedAt: day.AddDate(-2, 0, 0), ProofsGenerated: 1},
+		{CreatedAt: day.AddDate(0, -2, 0), ProofsGenerated: 2},
+		{CreatedAt: day.AddDate(0, 0, -3), ProofsGenerated: 3},
+	}}
+	c := NewStatsCache(s, time.Minute, time.Minute)
+	require.NoError(t, c.refresh())
+
+	// Current stats are queried once for all predefined ranges.
+	assert.Equal(t, 1, s.current)
+	assert.Equal(t, model.PredefinedStatsRanges(), s.ranges)
+
+	for r, want := range map[model.StatsRange][]model.Stats{
+		model.RangeWeek:     s.series[2:],
+		model.RangeMonth:    s.series[2:],
+		model.RangeHalfYear: s.series[1:],
+		model.RangeYear:     s.series[1:],
+		model.RangeAll:      s.series,
+	} {
+		stats, err := c.CachedStats(r)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(42), stats.Stats.ProofsGenerated)
+
+		series, err := c.CachedStatsSeries(r)
+		require.NoError(t, err)
+		assert.Equal(t, want, series, r.String())
+	}
+}
@@ -2,12 +2,17 @@
 package mock
 
 import (
+	"cmp"
+	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gevulotnetwork/devnet-explorer/model"
@@ -25,6 +30,7 @@ type Store struct {
 	eventMap   map[string]model.TxInfo
 	stats      model.CombinedStats
 	eventsCh   chan model.Event
+	running    atomic.Bool
 	done       chan struct{}
 }
 
@@ -36,23 +42,76 @@ func New() *Store {
 	}
 }
 
-func (s *Store) Stats(model.StatsRange) (model.CombinedStats, error) {
+func (s *Store) Stats(r model.StatsRange) (model.CombinedStats, error) {
+	stats, err := s.CurrentStats()
+	if err != nil {
+		return model.CombinedStats{}, err
+	}
+	return s.RangeStats(stats, r)
+}
+
+func (s *Store) CurrentStats() (model.Stats, error) {
 	s.stats.Stats.ProversDeployed += rand.Uint64() % 9000
 	s.stats.Stats.ProofsGenerated += rand.Uint64() % 9000
 	s.stats.Stats.ProofsVerified += rand.Uint64() % 9000
 	s.stats.Stats.RegisteredUsers += rand.Uint64() % 9000
-	s.stats.DeltaStats.ProversDeployed = rand.Float64() * 100
-	s.stats.DeltaStats.ProofsGenerated = rand.Float64() * 100
-	s.stats.DeltaStats.ProofsVerified = rand.Float64() * 100
-	s.stats.DeltaStats.RegisteredUsers = rand.Float64() * 100
-	return s.stats, nil
+	return s.stats.Stats, nil
+}
+
+func (s *Store) RangeStats(stats model.Stats, _ model.StatsRange) (model.CombinedStats, error) {
+	return model.CombinedStats{
+		Stats: stats,
+		DeltaStats: model.DeltaStats{
+			ProversDeployed: rand.Float64() * 100,
+			ProofsGenerated: rand.Float64() * 100,
+			ProofsVerified:  rand.Float64() * 100,
+			RegisteredUsers: rand.Float64() * 100,
+		},
+	}, nil
+}
+
+func (s *Store) StatsSeries(r model.StatsRange, b model.StatsBucket) ([]model.Stats, error) {
+	// Mock data covers at most two years, so that ranges starting at zero
+	// time don't generate millions of days.
+	since, until := r.Since(), r.Until()
+	if first := time.Now().AddDate(-2, 0, 0); since.Before(first) {
+		since = first
+	}
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	var series []model.Stats
+	var stats model.Stats
+	for t := since; t.Before(until); t = t.AddDate(0, 0, 1) {
+		stats.CreatedAt = t
+		stats.ProversDeployed += rand.Uint64() % 9000
+		stats.ProofsGenerated += rand.Uint64() % 9000
+		stats.ProofsVerified += rand.Uint64() % 9000
+		stats.RegisteredUsers += rand.Uint64() % 9000
+
+		switch {
+		case len(series) == 0,
+			b == model.BucketDay,
+			b == model.BucketWeek && t.Weekday() == time.Monday,
+			b == model.BucketMonth && t.Day() == 1:
+			series = append(series, stats)
+		default:
+			// Last stats of the bucket is used.
+			stats.CreatedAt = series[len(series)-1].CreatedAt
+			series[len(series)-1] = stats
+		}
+	}
+	return series, nil
 }
 
 func (s *Store) Run() error {
 	defer close(s.eventsCh)
+	s.running.Store(true)
+	defer s.running.Store(false)
 	for {
-		e := s.nextEvent()
 		s.eventsMu.Lock()
+		e := s.nextEvent()
 		s.events = append(s.events, e)
 		s.eventsMu.Unlock()
 		select {
@@ -68,43 +127,314 @@ func (s *Store) Events() <-chan model.Event {
 	return s.eventsCh
 }
 
-func (s *Store) Search(filter string) ([]model.Event, error) {
+func (s *Store) Search(f model.TxFilter) (model.SearchResult, error) {
+	page, err := s.Transactions(f, model.Page{Limit: 50})
+	if err != nil || f.Text == "" {
+		return model.SearchResult{Transactions: page.Events}, err
+	}
+
 	s.eventsMu.RLock()
 	defer s.eventsMu.RUnlock()
-	events := make([]model.Event, 0, 50)
+
+	res := model.SearchResult{Transactions: page.Events}
+	programs := make(map[string]bool)
+	accounts := make(map[string]int)
 	for i := len(s.events) - 1; i >= 0; i-- {
 		e := s.events[i]
-		if strings.Contains(e.ProverID, filter) || strings.Contains(e.TxID, filter) || strings.Contains(e.Tag, filter) {
-			events = append(events, e)
-			if len(events) == 50 {
-				return events, nil
+		if !programs[e.ProverID] && (f.MatchHash(e.ProverID) || f.MatchName(e.Tag)) {
+			programs[e.ProverID] = true
+			res.Programs = append(res.Programs, model.ProgramMatch{Hash: e.ProverID, Name: e.Tag})
+		}
+
+		author := s.eventMap[e.TxID].UserID
+		if !f.MatchHash(author) {
+			continue
+		}
+		if i, ok := accounts[author]; ok {
+			res.Accounts[i].Transactions++
+			continue
+		}
+		accounts[author] = len(res.Accounts)
+		res.Accounts = append(res.Accounts, model.AccountMatch{Key: author, Transactions: 1, LastSeen: e.Timestamp})
+	}
+	return res, nil
+}
+
+func (s *Store) Transactions(f model.TxFilter, p model.Page) (model.TxPage, error) {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	// add adds matching event to the page and reports if more events are needed.
+	events := make([]model.Event, 0, p.Limit+1)
+	add := func(e model.Event) bool {
+		e.Kind = model.KindRun
+		e.Author = s.eventMap[e.TxID].UserID
+		c := model.CursorOf(e)
+		switch {
+		case !p.After.IsZero() && !p.After.Less(c),
+			!p.Before.IsZero() && !c.Less(p.Before),
+			!f.Match(e):
+			return true
+		}
+
+		events = append(events, e)
+		return len(events) <= p.Limit
+	}
+
+	if p.After.IsZero() {
+		for i := len(s.events) - 1; i >= 0; i-- {
+			if !add(s.events[i]) {
+				break
+			}
+		}
+	} else {
+		for i := 0; i < len(s.events); i++ {
+			if !add(s.events[i]) {
+				break
 			}
 		}
 	}
-	return events, nil
+	return model.NewTxPage(events, p), nil
 }
 
 func (s *Store) TxInfo(id string) (model.TxInfo, error) {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
 	info, ok := s.eventMap[id]
 	if !ok {
-		return model.TxInfo{}, fmt.Errorf("tx %s not found", id)
+		return model.TxInfo{}, fmt.Errorf("tx %s: %w", id, model.ErrNotFound)
 	}
 	return info, nil
 }
 
-func (s *Store) LatestDailyStats() (model.Stats, error) {
-	return model.Stats{}, nil
+func (s *Store) Program(hash string) (model.ProgramInfo, error) {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	var p model.ProgramInfo
+	var latency time.Duration
+	seen := make(map[string]bool)
+	for i := len(s.events) - 1; i >= 0; i-- {
+		e := s.events[i]
+		if e.ProverID != hash || seen[e.TxID] {
+			continue
+		}
+		seen[e.TxID] = true
+
+		e.Kind = model.KindRun
+		e.Author = s.eventMap[e.TxID].UserID
+		if len(p.RecentRuns) < 10 {
+			p.RecentRuns = append(p.RecentRuns, e)
+		}
+
+		p.Stats.Runs++
+		if info := s.eventMap[e.TxID]; info.State == model.StateComplete {
+			p.Stats.Completed++
+			latency += info.Duration
+		}
+	}
+
+	if p.Stats.Runs == 0 {
+		return model.ProgramInfo{}, fmt.Errorf("program %s: %w", hash, model.ErrNotFound)
+	}
+
+	checksum := sha512.Sum512([]byte(hash))
+	p.Program = model.Program{
+		Hash:              hash,
+		Name:              p.RecentRuns[0].Tag,
+		ImageFileName:     "prover.img",
+		ImageFileURL:      "http://localhost/" + hash + "/prover.img",
+		ImageFileChecksum: hex.EncodeToString(checksum[:32]),
+		Memory:            512,
+		CPUs:              2,
+		Prover:            true,
+	}
+	p.Stats.SuccessRate = float64(p.Stats.Completed) / float64(p.Stats.Runs) * 100
+	if p.Stats.Completed > 0 {
+		p.Stats.AvgLatency = latency / time.Duration(p.Stats.Completed)
+	}
+	return p, nil
+}
+
+func (s *Store) Account(key string) (model.AccountInfo, error) {
+	page, err := s.Transactions(model.TxFilter{Author: key}, model.Page{Limit: 10})
+	if err != nil {
+		return model.AccountInfo{}, err
+	}
+
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	acc := model.AccountInfo{
+		Account:            model.Account{Key: key, Whitelisted: true, Transactions: make(map[string]uint64)},
+		RecentTransactions: page.Events,
+	}
+	seen := func(t time.Time) {
+		if acc.FirstSeen.IsZero() || t.Before(acc.FirstSeen) {
+			acc.FirstSeen = t
+		}
+		if t.After(acc.LastSeen) {
+			acc.LastSeen = t
+		}
+	}
+
+	for _, info := range s.eventMap {
+		for _, l := range info.Log {
+			if l.ID != key {
+				continue
+			}
+
+			switch l.State {
+			case model.StateSubmitted:
+				acc.Transactions["run"]++
+			case model.StateProving:
+				acc.Transactions["proof"]++
+			default:
+				acc.Transactions["verification"]++
+			}
+			seen(l.Timestamp)
+		}
+	}
+
+	if len(acc.Transactions) == 0 {
+		return model.AccountInfo{}, fmt.Errorf("account %s: %w", key, model.ErrNotFound)
+	}
+	return acc, nil
+}
+
+func (s *Store) Leaderboard(r model.StatsRange) ([]model.NodeRank, error) {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	since := r.Since()
+	ranks := make(map[string]*model.NodeRank)
+	latencies := make(map[string][]time.Duration)
+	total := 0
+	for _, info := range s.eventMap {
+		for _, l := range info.Log {
+			if l.State == model.StateSubmitted || l.Timestamp.Before(since) {
+				continue
+			}
+
+			n, ok := ranks[l.ID]
+			if !ok {
+				n = &model.NodeRank{Node: l.ID}
+				ranks[l.ID] = n
+			}
+			if l.State == model.StateProving {
+				n.Proofs++
+			} else {
+				n.Verifications++
+			}
+			latencies[l.ID] = append(latencies[l.ID], l.Timestamp.Sub(info.Log[0].Timestamp))
+			total++
+		}
+	}
+
+	nodes := make([]model.NodeRank, 0, len(ranks))
+	for id, n := range ranks {
+		l := latencies[id]
+		slices.Sort(l)
+		n.MedianLatency = l[len(l)/2]
+		n.Share = float64(n.Proofs+n.Verifications) / float64(total) * 100
+		nodes = append(nodes, *n)
+	}
+
+	slices.SortFunc(nodes, func(a, b model.NodeRank) int {
+		if c := cmp.Compare(b.Proofs+b.Verifications, a.Proofs+a.Verifications); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Node, b.Node)
+	})
+	return nodes, nil
+}
+
+func (s *Store) Payload(tx string, offset, length int) (model.Payload, []byte, error) {
+	s.eventsMu.RLock()
+	defer s.eventsMu.RUnlock()
+
+	for _, info := range s.eventMap {
+		for _, p := range info.Payloads {
+			if p.TxID != tx {
+				continue
+			}
+
+			data := payloadData(p.TxID, p.Size)
+			offset = min(offset, len(data))
+			if length <= 0 || offset+length > len(data) {
+				length = len(data) - offset
+			}
+			return p, data[offset : offset+length], nil
+		}
+	}
+	return model.Payload{}, nil, fmt.Errorf("payload of tx %s: %w", tx, model.ErrNotFound)
+}
+
+// MissingDailyStats reports no missing days as mock stats aren't stored.
+func (s *Store) MissingDailyStats(time.Time) ([]time.Time, error) {
+	return nil, nil
+}
+
+// Migrate does nothing as mock store has no schema.
+func (s *Store) Migrate() error {
+	return nil
 }
 
 func (s *Store) AggregateStats(time.Time) error {
 	return nil
 }
 
+// IsLeader reports true as mock store isn't shared between replicas.
+func (s *Store) IsLeader() (bool, error) {
+	return true, nil
+}
+
+func (s *Store) HourlyStats(since, until time.Time) ([]model.HourlyStats, error) {
+	var stats []model.HourlyStats
+	for h := since.Truncate(time.Hour); h.Before(until); h = h.Add(time.Hour) {
+		if h.Before(since) {
+			continue
+		}
+		p50 := time.Duration(30+rand.Intn(60)) * time.Second
+		stats = append(stats, model.HourlyStats{
+			Hour:          h,
+			Runs:          uint64(rand.Intn(100)),
+			Proofs:        uint64(rand.Intn(100)),
+			Verifications: uint64(rand.Intn(300)),
+			LatencyP50:    p50,
+			LatencyP95:    p50 + time.Duration(rand.Intn(120))*time.Second,
+		})
+	}
+	return stats, nil
+}
+
+func (s *Store) LatestHourlyStats() (model.HourlyStats, error) {
+	return model.HourlyStats{}, nil
+}
+
+func (s *Store) AggregateHourlyStats(time.Time) error {
+	return nil
+}
+
+func (s *Store) DeleteHourlyStats(time.Time) error {
+	return nil
+}
+
+func (s *Store) Ready() error {
+	if !s.running.Load() {
+		return errors.New("mock store not running")
+	}
+	return nil
+}
+
 func (s *Store) Stop() error {
 	close(s.done)
 	return nil
 }
 
+// nextEvent advances a random event of the queue. It updates eventMap, so
+// eventsMu must be held.
 func (s *Store) nextEvent() model.Event {
 	i := rand.Intn(Parallelism)
 	switch s.eventQueue[i].State {
@@ -119,6 +449,7 @@ func (s *Store) nextEvent() model.Event {
 			ID:        s.eventQueue[i].ProverID,
 			Timestamp: s.eventQueue[i].Timestamp,
 		})
+		info.Payloads = append(info.Payloads, newPayload(model.PayloadProof, info.TxID, len(info.Payloads)))
 		s.eventMap[s.eventQueue[i].TxID] = info
 
 	case model.StateProving:
@@ -133,6 +464,7 @@ func (s *Store) nextEvent() model.Event {
 			ID:        hex.EncodeToString(verifierID[:]),
 			Timestamp: s.eventQueue[i].Timestamp,
 		})
+		info.Payloads = append(info.Payloads, newPayload(model.PayloadVerification, info.TxID, len(info.Payloads)))
 		s.eventMap[s.eventQueue[i].TxID] = info
 
 	case model.StateVerifying:
@@ -156,6 +488,7 @@ func (s *Store) nextEvent() model.Event {
 		}
 
 		info.Log = append(s.eventMap[s.eventQueue[i].TxID].Log, log)
+		info.Payloads = append(info.Payloads, newPayload(model.PayloadVerification, info.TxID, len(info.Payloads)))
 		s.eventMap[s.eventQueue[i].TxID] = info
 
 	case model.StateUnknown, model.StateComplete:
@@ -164,9 +497,14 @@ func (s *Store) nextEvent() model.Event {
 		userID := sha512.Sum512([]byte(time.Now().String()))
 		s.eventMap[s.eventQueue[i].TxID] = model.TxInfo{
 			State:    model.StateProving,
+			Kind:     model.KindRun,
 			TxID:     s.eventQueue[i].TxID,
 			UserID:   hex.EncodeToString(userID[:]),
 			ProverID: s.eventQueue[i].ProverID,
+			Workflow: randomWorkflow(s.eventQueue[i]),
+			Files: []model.TxFile{
+				{TxID: s.eventQueue[i].TxID, Name: "proof.bin", URL: "http://localhost/" + s.eventQueue[i].TxID + "/proof.bin", Checksum: s.eventQueue[i].TxID[:64]},
+			},
 			Log: []model.TxLogEvent{
 				{
 					State:     model.StateSubmitted,
@@ -192,3 +530,50 @@ func randomEvent() model.Event {
 		Timestamp: time.Now().Local(),
 	}
 }
+
+func randomWorkflow(e model.Event) []model.WorkflowStep {
+	verifierID := sha512.Sum512([]byte(e.ProverID))
+	verifier := hex.EncodeToString(verifierID[:32])
+	return []model.WorkflowStep{
+		{
+			Sequence:    1,
+			Program:     e.ProverID,
+			ProgramName: e.Tag,
+			Args:        []string{"--mode", "prove", "--input", "witness.json"},
+			Inputs: []model.InputFile{
+				{Name: "witness.json", URL: "http://localhost/" + e.TxID + "/witness.json", Checksum: e.TxID[64:]},
+			},
+		},
+		{
+			Sequence: 2,
+			Program:  verifier,
+			Args:     []string{"--mode", "verify"},
+			Outputs: []model.OutputFile{
+				{Name: "proof.bin", SourceProgram: e.ProverID},
+			},
+		},
+	}
+}
+
+// newPayload returns nth payload of the run.
+func newPayload(kind, runID string, n int) model.Payload {
+	txID := sha512.Sum512([]byte(fmt.Sprintf("%s/%d", runID, n)))
+	p := model.Payload{
+		TxID: hex.EncodeToString(txID[:]),
+		Kind: kind,
+		Size: 1024 + rand.Intn(64*1024),
+	}
+	digest := sha256.Sum256(payloadData(p.TxID, p.Size))
+	p.SHA256 = hex.EncodeToString(digest[:])
+	return p
+}
+
+// payloadData returns deterministic payload data of given size for the tx.
+func payloadData(txID string, size int) []byte {
+	seed := sha512.Sum512([]byte(txID))
+	data := make([]byte, 0, size+len(seed))
+	for len(data) < size {
+		data = append(data, seed[:]...)
+	}
+	return data[:size]
+}
@@ -0,0 +1,175 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Keys of the advisory locks held while a replica migrates the database or
+// creates indexes, so that replicas starting together don't race.
+const (
+	migrationLockID = aggregatorLockID + 1
+	indexLockID     = aggregatorLockID + 2
+)
+
+// migration is a change the store can't work without. Each migration is
+// applied once in its own transaction and recorded in explorer_migrations.
+type migration struct {
+	name  string
+	query string
+}
+
+// migrations are applied in order. Applied migrations must not be changed,
+// changes go to new migrations.
+var migrations = []migration{
+	{
+		// Hourly throughput and latency rollups written by the stats aggregator.
+		name: "create_hourly_stats",
+		query: `
+			CREATE TABLE IF NOT EXISTS hourly_stats (
+				hour timestamp with time zone PRIMARY KEY,
+				runs bigint NOT NULL,
+				proofs bigint NOT NULL,
+				verifications bigint NOT NULL,
+				latency_p50 double precision NOT NULL,
+				latency_p95 double precision NOT NULL
+			)`,
+	},
+	{
+		// One daily stats row per UTC day, which the aggregator upserts.
+		// Duplicates are removed first, keeping the latest row of each day.
+		name: "daily_stats_unique_day",
+		query: `
+			DELETE FROM daily_stats AS a USING daily_stats AS b
+			WHERE (a.created_at AT TIME ZONE 'UTC')::date = (b.created_at AT TIME ZONE 'UTC')::date
+				AND (a.created_at, a.ctid) < (b.created_at, b.ctid);
+			CREATE UNIQUE INDEX IF NOT EXISTS daily_stats_day_idx ON daily_stats (((created_at AT TIME ZONE 'UTC')::date))`,
+	},
+	{
+		// Daily stats used to be stamped when they were aggregated. The old
+		// aggregator checked every minute whether the UTC day had changed, so
+		// rows stamped soon after midnight hold totals at the end of the day
+		// before. They are moved to the start of that day like reconstructed
+		// stats, which are kept if both exist. Rows stamped later in the day
+		// were written after a restart and hold totals of that day so far,
+		// so they are removed and their days are aggregated again at startup.
+		// Rows are moved while the unique index is dropped, since moved rows
+		// may collide with rows not yet moved.
+		name: "daily_stats_start_of_day",
+		query: `
+			DROP INDEX IF EXISTS daily_stats_day_idx;
+			DELETE FROM daily_stats
+			WHERE created_at >= date_trunc('day', created_at, 'UTC') + interval '1 hour';
+			DELETE FROM daily_stats AS legacy USING daily_stats AS stats
+			WHERE legacy.created_at <> date_trunc('day', legacy.created_at, 'UTC')
+				AND stats.created_at = date_trunc('day', legacy.created_at, 'UTC') - interval '1 day';
+			UPDATE daily_stats SET created_at = date_trunc('day', created_at, 'UTC') - interval '1 day'
+			WHERE created_at <> date_trunc('day', created_at, 'UTC');
+			CREATE UNIQUE INDEX daily_stats_day_idx ON daily_stats (((created_at AT TIME ZONE 'UTC')::date))`,
+	},
+	{
+		// Program names are searched by trigram similarity.
+		name:  "create_pg_trgm_extension",
+		query: `CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+	},
+}
+
+// Migrate applies migrations which haven't been applied yet. It must succeed
+// before stats are aggregated.
+func (s *Store) Migrate() error {
+	return s.withLock(migrationLockID, true, func(conn *sql.Conn) error {
+		const createQuery = `
+			CREATE TABLE IF NOT EXISTS explorer_migrations (
+				name text PRIMARY KEY,
+				applied_at timestamp with time zone NOT NULL DEFAULT now()
+			)`
+		if _, err := conn.ExecContext(s.ctx, createQuery); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+
+		for _, m := range migrations {
+			if err := s.migrate(conn, m); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) migrate(conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(s.ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	res, err := tx.ExecContext(s.ctx, `INSERT INTO explorer_migrations (name) VALUES ($1) ON CONFLICT DO NOTHING`, m.name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	slog.Info("applying migration", slog.String("name", m.name))
+	if _, err := tx.ExecContext(s.ctx, m.query); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applySchema creates indexes used by the store queries if they don't exist,
+// unless another replica is already creating them. Statements are executed one
+// by one outside of transactions, so indexes can be created concurrently
+// without blocking writes.
+func (s *Store) applySchema() error {
+	return s.withLock(indexLockID, false, func(conn *sql.Conn) error {
+		for _, stmt := range strings.Split(schema, ";") {
+			if strings.TrimSpace(stmt) == "" {
+				continue
+			}
+			if _, err := conn.ExecContext(s.ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withLock calls fn with a connection holding the advisory lock. If wait is
+// false and another session holds the lock, fn isn't called.
+func (s *Store) withLock(id int64, wait bool, fn func(*sql.Conn) error) error {
+	conn, err := s.db.Db.Conn(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if wait {
+		_, err = conn.ExecContext(s.ctx, `SELECT pg_advisory_lock($1)`, id)
+	} else {
+		locked := false
+		err = conn.QueryRowContext(s.ctx, `SELECT pg_try_advisory_lock($1)`, id).Scan(&locked)
+		if err == nil && !locked {
+			slog.Info("lock held by another replica, skipping", slog.Int64("lock", id))
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, id); err != nil {
+			slog.Error("failed to release lock", slog.Any("err", err))
+		}
+	}()
+
+	return fn(conn)
+}
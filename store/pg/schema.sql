@@ -0,0 +1,22 @@
+-- Indexes used by the explorer queries. Queries work without them, so they are
+-- created in the background. Statements are executed one by one, so indexes can
+-- be created concurrently without blocking writes. Changes the store can't work
+-- without are migrations in migrate.go.
+
+-- Hash prefix search.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS transaction_hash_pattern_idx ON transaction (hash text_pattern_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS transaction_author_pattern_idx ON transaction (author text_pattern_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS workflow_step_program_pattern_idx ON workflow_step (program text_pattern_ops);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS program_hash_pattern_idx ON program (hash text_pattern_ops);
+
+-- Transaction list pagination.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS transaction_created_at_hash_idx ON transaction (created_at, hash);
+
+-- Workflow state lookups.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS proof_parent_idx ON proof (parent);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS verification_parent_idx ON verification (parent);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS proof_key_parent_idx ON proof_key (parent);
+
+-- Program name substring and similarity search. The pg_trgm extension is
+-- created by a migration.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS program_name_trgm_idx ON program USING gin (name gin_trgm_ops);
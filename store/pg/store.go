@@ -4,67 +4,32 @@ package pg
 import (
 	"context"
 	"database/sql"
-	"database/sql/driver"
+	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gevulotnetwork/devnet-explorer/metrics"
 	"github.com/gevulotnetwork/devnet-explorer/model"
 	"github.com/go-gorp/gorp/v3"
 	"github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-// Gevulot Transaction Kind type
-const (
-	run          txKind = "run"
-	proof        txKind = "proof"
-	verification txKind = "verification"
-)
-
-type txKind string
-
-func (k *txKind) Scan(value interface{}) error {
-	v, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("invalid transaction kind value: %#v", value)
-	}
-
-	switch v {
-	case "run":
-		*k = run
-	case "proof":
-		*k = proof
-	case "verification":
-		*k = verification
-	default:
-		return fmt.Errorf("unrecognized transaction kind type: %q", v)
-	}
-
-	return nil
-}
-
-func (k *txKind) Value() (driver.Value, error) {
-	switch *k {
-	case run:
-		return int64(1), nil
-	case proof:
-		return int64(2), nil
-	case verification:
-		return int64(3), nil
-	default:
-		return int64(0), fmt.Errorf("unrecognized transaction kind type: %q", *k)
-	}
-}
+//go:embed schema.sql
+var schema string
 
 type gevulotTransaction struct {
 	Author     string
 	Hash       string
-	Kind       txKind
+	Kind       model.TxKind
 	Nonce      int    //nolint: unused
 	Signature  string //nolint: unused
 	Propagated bool   //nolint: unused
@@ -73,10 +38,15 @@ type gevulotTransaction struct {
 }
 
 type Store struct {
-	db     *gorp.DbMap
-	events chan model.Event
-	ctx    context.Context
-	cancel context.CancelFunc
+	db        *gorp.DbMap
+	events    chan model.Event
+	listening atomic.Bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	// lockConn holds the session owning the aggregator lock.
+	lockMu   sync.Mutex
+	lockConn *sql.Conn
 }
 
 func New(dsn string) (*Store, error) {
@@ -94,45 +64,216 @@ func New(dsn string) (*Store, error) {
 	}, nil
 }
 
+const (
+	// Backoff limits for reconnecting notification listener.
+	minReconnectBackoff = 500 * time.Millisecond
+	maxReconnectBackoff = 30 * time.Second
+
+	// backfillPageSize is the number of events queried at once when
+	// backfilling events after reconnecting.
+	backfillPageSize = 1000
+
+	// aggregatorLockID is the key of the advisory lock held by the replica
+	// aggregating stats.
+	aggregatorLockID int64 = 0x6465766e6574 // "devnet"
+)
+
+// Run listens for new events until the store is stopped. If listening fails,
+// it reconnects with exponential backoff and backfills events of transactions
+// created while it was disconnected.
 func (s *Store) Run() error {
 	defer close(s.events)
 
-	conn, err := s.db.Db.Conn(context.Background())
+	// Indexes are created in the background since creating them may take a
+	// while, queries work without them, only slower.
+	go func() {
+		if err := s.applySchema(); err != nil {
+			slog.Error("failed to apply schema", slog.Any("err", err))
+		}
+	}()
+
+	var since time.Time
+	backoff := minReconnectBackoff
+	for {
+		connected, err := s.listen(&since)
+		if s.ctx.Err() != nil {
+			slog.Info("pg notify listener stopped by context")
+			return nil
+		}
+
+		if connected {
+			backoff = minReconnectBackoff
+		}
+
+		slog.Error("pg notify listener failed, reconnecting", slog.Any("err", err), slog.Duration("backoff", backoff))
+		metrics.ListenerReconnects.Inc()
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			slog.Info("pg notify listener stopped by context")
+			return nil
+		}
+		backoff = min(2*backoff, maxReconnectBackoff)
+	}
+}
+
+// listen listens for notifications on a single connection until it fails.
+// since is the time up to which events are known to be received, if it's
+// set events of transactions created after it are backfilled before listening.
+// Returned bool tells if listening was started successfully.
+func (s *Store) listen(since *time.Time) (bool, error) {
+	conn, err := s.db.Db.Conn(s.ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get connection for listen/notify: %w", err)
+		return false, fmt.Errorf("failed to get connection for listen/notify: %w", err)
 	}
+	defer conn.Close()
 
-	return conn.Raw(func(driverConn any) error {
+	connected := false
+	err = conn.Raw(func(driverConn any) error {
 		conn := driverConn.(*stdlib.Conn).Conn()
-		_, err := conn.Exec(context.Background(), "listen dashboard_data_stream")
+		_, err := conn.Exec(s.ctx, "listen dashboard_data_stream")
 		if err != nil {
 			return err
 		}
+		connected = true
+		s.listening.Store(true)
+		defer s.listening.Store(false)
 
-		for {
-			n, err := conn.WaitForNotification(s.ctx)
-			if errors.Is(err, context.Canceled) {
-				slog.Info("pg notify listener stopped by context")
-				return nil
+		// Notifications are received from this point on, so anything created
+		// before now has to be backfilled. Time is taken from the database, as
+		// it's compared to creation times set by the database.
+		var now time.Time
+		if err := conn.QueryRow(s.ctx, "SELECT now()").Scan(&now); err != nil {
+			return fmt.Errorf("failed to query database time: %w", err)
+		}
+		if !since.IsZero() {
+			if err := s.backfill(*since); err != nil {
+				return fmt.Errorf("failed to backfill events: %w", err)
 			}
+		}
+		*since = now
 
+		for {
+			n, err := conn.WaitForNotification(s.ctx)
 			if err != nil {
 				return fmt.Errorf("error occurred while waiting for notification: %w", err)
 			}
 
 			slog.Debug("received notification", slog.String("payload", n.Payload))
+			metrics.EventsReceived.Inc()
+			if err := conn.QueryRow(s.ctx, "SELECT now()").Scan(since); err != nil {
+				return fmt.Errorf("failed to query database time: %w", err)
+			}
 			e := model.Event{}
 			if err = json.Unmarshal([]byte(n.Payload), &e); err != nil {
 				return fmt.Errorf("notification payload '%s': %w", n.Payload, err)
 			}
+			if err := s.describeEvent(&e); err != nil {
+				slog.Error("failed to describe event", slog.String("tx_id", e.TxID), slog.Any("err", err))
+			}
 
-			select {
-			case s.events <- e:
-			case <-time.After(time.Minute):
-				return errors.New("timeout waiting for event to be sent")
+			if err := s.send(e); err != nil {
+				return err
 			}
 		}
 	})
+	return connected, err
+}
+
+// describeEvent sets fields of live run event which aren't in the notification
+// payload, so that events match the same search filters as stored
+// transactions.
+func (s *Store) describeEvent(e *model.Event) error {
+	e.Kind = model.KindRun
+	const query = `
+		SELECT t.author, COALESCE(pr.name, '') AS tag
+		FROM transaction AS t
+		LEFT JOIN workflow_step AS ws ON ws.tx = t.hash AND ws.sequence = 1
+		LEFT JOIN program AS pr ON pr.hash = ws.program
+		WHERE t.hash = $1`
+
+	var row struct {
+		Author string `db:"author"`
+		Tag    string `db:"tag"`
+	}
+	if err := s.db.SelectOne(&row, query, e.TxID); err != nil {
+		return err
+	}
+
+	e.Author = row.Author
+	if e.Tag == "" {
+		e.Tag = row.Tag
+	}
+	return nil
+}
+
+// backfill sends events of runs that had new transactions created after since.
+// Events are queried in pages ordered by creation time and hash of the run.
+func (s *Store) backfill(since time.Time) error {
+	const query = `
+		WITH runs AS (
+			SELECT t.hash FROM transaction AS t WHERE t.kind = 'run' AND t.created_at > $1
+			UNION
+			SELECT p.parent FROM transaction AS t JOIN proof AS p ON p.tx = t.hash WHERE t.created_at > $1
+			UNION
+			SELECT p.parent FROM transaction AS t JOIN verification AS v ON v.tx = t.hash JOIN proof AS p ON v.parent = p.tx WHERE t.created_at > $1
+		)
+		SELECT
+			CASE WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = t.hash) > 2 THEN 'Complete'
+				WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = t.hash) >= 1 THEN 'Verifying'
+				WHEN (SELECT COUNT(*) FROM proof WHERE parent = t.hash) >= 1 THEN 'Proving'
+				ELSE 'Submitted'
+			END AS state,
+			t.hash AS tx_id,
+			'run' AS kind,
+			t.author,
+			COALESCE(ws.program, '') AS prover_id,
+			COALESCE(pr.name, '') AS tag,
+			t.created_at AS timestamp
+		FROM transaction AS t
+		JOIN runs ON runs.hash = t.hash
+		LEFT JOIN workflow_step AS ws ON ws.tx = t.hash AND ws.sequence = 1
+		LEFT JOIN program AS pr ON pr.hash = ws.program
+		WHERE $2::timestamptz IS NULL OR (t.created_at, t.hash) > ($2, $3)
+		ORDER BY t.created_at ASC, t.hash ASC
+		LIMIT $4`
+
+	var (
+		after *time.Time
+		hash  string
+		sent  int
+	)
+	for {
+		var events []model.Event
+		if _, err := s.db.Select(&events, query, since, after, hash, backfillPageSize); err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if err := s.send(e); err != nil {
+				return err
+			}
+		}
+		sent += len(events)
+
+		if len(events) < backfillPageSize {
+			slog.Info("backfilled events missed while disconnected", slog.Time("since", since), slog.Int("events", sent))
+			return nil
+		}
+		last := events[len(events)-1]
+		after, hash = &last.Timestamp, last.TxID
+	}
+}
+
+func (s *Store) send(e model.Event) error {
+	select {
+	case s.events <- e:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case <-time.After(time.Minute):
+		return errors.New("timeout waiting for event to be sent")
+	}
 }
 
 func (s *Store) CurrentStats() (model.Stats, error) {
@@ -152,12 +293,27 @@ func (s *Store) CurrentStats() (model.Stats, error) {
 	return stats, nil
 }
 
-// Stats returns stats for the given time range.
+// Stats returns stats for the given time range. Stats of ranges ending
+// before now are taken from the latest daily stats before the end.
 func (s *Store) Stats(r model.StatsRange) (model.CombinedStats, error) {
-	stats, err := s.CurrentStats()
+	var stats model.Stats
+	var err error
+	if end := r.Until(); !end.IsZero() {
+		stats, err = s.statsAt(end)
+	} else {
+		stats, err = s.CurrentStats()
+	}
 	if err != nil {
 		return model.CombinedStats{}, fmt.Errorf("failed to get current stats: %w", err)
 	}
+	return s.RangeStats(stats, r)
+}
+
+// RangeStats returns stats at the end of the range with their change within
+// the range. Callers computing several ranges ending now can share current
+// stats.
+func (s *Store) RangeStats(stats model.Stats, r model.StatsRange) (model.CombinedStats, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("stats")).ObserveDuration()
 
 	// Get oldest record within the given time range.
 	const oldStatsQuery = `
@@ -166,13 +322,13 @@ func (s *Store) Stats(r model.StatsRange) (model.CombinedStats, error) {
 	FROM
 		daily_stats
 	WHERE
-		created_at > $1
+		created_at > $1 AND ($2::timestamptz IS NULL OR created_at < $2)
 	ORDER BY
 		created_at ASC
 	LIMIT 1`
 
 	var oldStats model.Stats
-	err = s.db.SelectOne(&oldStats, oldStatsQuery, r.Since())
+	err := s.db.SelectOne(&oldStats, oldStatsQuery, r.Since(), until(r))
 	if errors.Is(err, sql.ErrNoRows) {
 		slog.Info("no old stats found, showing only current stats")
 		return model.CombinedStats{Stats: stats}, nil
@@ -185,76 +341,256 @@ func (s *Store) Stats(r model.StatsRange) (model.CombinedStats, error) {
 	return model.CombinedStats{
 		Stats: stats,
 		DeltaStats: model.DeltaStats{
-			ProofsGenerated: (float64(stats.ProofsGenerated-oldStats.ProofsGenerated) / float64(oldStats.ProofsGenerated)) * 100,
-			ProofsVerified:  (float64(stats.ProofsVerified-oldStats.ProofsVerified) / float64(oldStats.ProofsVerified)) * 100,
-			ProversDeployed: (float64(stats.ProversDeployed-oldStats.ProversDeployed) / float64(oldStats.ProversDeployed)) * 100,
-			RegisteredUsers: (float64(stats.RegisteredUsers-oldStats.RegisteredUsers) / float64(oldStats.RegisteredUsers)) * 100,
+			ProofsGenerated: delta(stats.ProofsGenerated, oldStats.ProofsGenerated),
+			ProofsVerified:  delta(stats.ProofsVerified, oldStats.ProofsVerified),
+			ProversDeployed: delta(stats.ProversDeployed, oldStats.ProversDeployed),
+			RegisteredUsers: delta(stats.RegisteredUsers, oldStats.RegisteredUsers),
 		},
 	}, nil
 }
 
-func (s *Store) Search(filter string) ([]model.Event, error) {
-	filter = strings.TrimSpace(filter)
+// statsAt returns the latest daily stats before t or zero stats if there are
+// none.
+func (s *Store) statsAt(t time.Time) (model.Stats, error) {
+	const statsAtQuery = `
+		SELECT * FROM daily_stats
+		WHERE created_at < $1
+		ORDER BY created_at DESC
+		LIMIT 1`
 
-	// filter string: free text search input straight from the user, handle as such.
-	// This query should return 50 most recent matching events sorted by timestamp in newest first order.
-	const query = `
-		 WITH t2 AS ((
-			(SELECT created_at, t.hash, t.kind FROM transaction AS t WHERE t.hash = $1)
-			UNION ALL
-			(SELECT created_at, t.hash, t.kind FROM transaction AS t JOIN workflow_step AS ws ON ws.tx=t.hash WHERE ws.sequence=1 AND ws.program = $1)
-			UNION ALL
-			(SELECT created_at, t.hash, t.kind FROM transaction AS t JOIN proof AS p ON t.hash = p.tx WHERE p.prover = $1)
-			UNION ALL
-			(SELECT created_at, t.hash, t.kind FROM transaction AS t JOIN verification AS v ON t.hash = v.tx JOIN proof AS p ON v.parent = p.tx WHERE p.prover = $1)
-		) ORDER BY created_at DESC LIMIT 50)
-		SELECT
-			CASE WHEN t2.kind = 'run' THEN
-					CASE WHEN (SELECT COUNT(*) FROM proof WHERE parent = t2.hash) = 0 THEN 'Submitted'
-						WHEN ((SELECT COUNT(*) FROM proof WHERE parent = t2.hash) >= 1 AND (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = t2.hash) = 0) IS TRUE THEN 'Proving'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = t2.hash) = 1 THEN 'Verifying'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = t2.hash) = 2 THEN 'Verifying'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = t2.hash) > 2 THEN 'Complete'
-					END
-				WHEN t2.kind = 'proof' THEN
-					CASE WHEN (SELECT COUNT(*) FROM proof WHERE parent = (SELECT parent FROM proof WHERE tx = t2.hash)) = 1 THEN 'Proving'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = (SELECT parent FROM proof WHERE tx = t2.hash)) = 1 THEN 'Verifying'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = (SELECT parent FROM proof WHERE tx = t2.hash)) = 2 THEN 'Verifying'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = (SELECT parent FROM proof WHERE tx = t2.hash)) > 2 THEN 'Complete'
-					END
-				WHEN t2.kind = 'verification' THEN
-					CASE WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = (SELECT p2.parent FROM proof AS p2 JOIN verification AS v2 ON p2.tx=v2.parent WHERE v2.tx = t2.hash)) = 1 THEN 'Verifying'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = (SELECT p2.parent FROM proof AS p2 JOIN verification AS v2 ON p2.tx=v2.parent WHERE v2.tx = t2.hash)) = 2 THEN 'Verifying'
-						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent=p.tx WHERE p.parent = (SELECT p2.parent FROM proof AS p2 JOIN verification AS v2 ON p2.tx=v2.parent WHERE v2.tx = t2.hash)) > 2 THEN 'Complete'
-					END
-			END AS state,
-			t.hash AS tx_id,
-			(
-				(SELECT name AS tag FROM program AS p JOIN workflow_step AS ws ON p.hash = ws.program JOIN t2 ON ws.tx = t2.hash WHERE ws.sequence = 1)
-			UNION
-				(SELECT name AS tag FROM program WHERE hash = $1)
-			),
-			(
-				(SELECT program AS prover_id FROM workflow_step AS ws JOIN t2 ON ws.tx = t2.hash WHERE ws.sequence = 1)
-			UNION
-				(SELECT hash AS prover_id FROM program WHERE hash = $1)
-			),
-			t.created_at AS timestamp FROM transaction AS t JOIN t2 ON t.hash = t2.hash
-		`
+	var stats model.Stats
+	err := s.db.SelectOne(&stats, statsAtQuery, t)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Stats{CreatedAt: t}, nil
+	}
+	return stats, err
+}
 
-	var events []model.Event
+// until returns end of the range as a query argument, nil for ranges ending
+// now.
+func until(r model.StatsRange) any {
+	if t := r.Until(); !t.IsZero() {
+		return t
+	}
+	return nil
+}
 
-	if _, err := s.db.Select(&events, query, filter); err != nil {
-		return nil, err
+// delta returns change from old to new in percent. Change from zero is
+// reported as zero since it can't be expressed as a percentage.
+func delta(cur, old uint64) float64 {
+	if old == 0 {
+		return 0
 	}
+	return (float64(cur) - float64(old)) / float64(old) * 100
+}
+
+// StatsSeries returns daily stats within the range in oldest first order.
+// Stats are downsampled by taking the last stats of each bucket.
+func (s *Store) StatsSeries(r model.StatsRange, b model.StatsBucket) ([]model.Stats, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("stats_series")).ObserveDuration()
+	const seriesQuery = `
+		SELECT DISTINCT ON (date_trunc($2, created_at))
+			date_trunc($2, created_at) AS created_at,
+			registered_users,
+			proofs_generated,
+			programs,
+			proofs_verified
+		FROM daily_stats
+		WHERE created_at > $1 AND ($3::timestamptz IS NULL OR created_at < $3)
+		ORDER BY date_trunc($2, created_at) ASC, created_at DESC`
 
-	return events, nil
+	var series []model.Stats
+	if _, err := s.db.Select(&series, seriesQuery, r.Since(), string(b), until(r)); err != nil {
+		return nil, fmt.Errorf("failed to query stats series: %w", err)
+	}
+	return series, nil
+}
+
+// likeEscaper escapes LIKE pattern special characters.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Search returns programs and accounts matching free text of the filter and
+// 50 most recent transactions matching the filter in newest first order.
+// Programs with names most similar to the text are listed first.
+func (s *Store) Search(f model.TxFilter) (model.SearchResult, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("search")).ObserveDuration()
+
+	var res model.SearchResult
+	if f.Text != "" {
+		op, hash := hashMatch(f.Text, f.TextPrefix)
+		programsQuery := fmt.Sprintf(`
+			SELECT hash, name FROM program
+			WHERE hash %s $1 OR name ILIKE $2 OR name %% $3
+			ORDER BY similarity(name, $3) DESC, name, hash
+			LIMIT 10`, op)
+		if _, err := s.db.Select(&res.Programs, programsQuery, hash, "%"+likeEscaper.Replace(f.Text)+"%", f.Text); err != nil {
+			return model.SearchResult{}, fmt.Errorf("failed to search programs: %w", err)
+		}
+
+		accountsQuery := fmt.Sprintf(`
+			SELECT author AS key, COUNT(*) AS transactions, MAX(created_at) AS last_seen FROM transaction
+			WHERE author %s $1
+			GROUP BY author
+			ORDER BY last_seen DESC
+			LIMIT 10`, op)
+		if _, err := s.db.Select(&res.Accounts, accountsQuery, hash); err != nil {
+			return model.SearchResult{}, fmt.Errorf("failed to search accounts: %w", err)
+		}
+	}
+
+	page, err := s.transactions(f, model.Page{Limit: 50})
+	if err != nil {
+		return model.SearchResult{}, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	res.Transactions = page.Events
+	return res, nil
+}
+
+// hashMatch returns SQL operator and its argument for matching hashes with
+// the value, same as model.TxFilter.MatchHash with prefix as TextPrefix.
+func hashMatch(value string, prefix bool) (op string, arg string) {
+	if prefix {
+		return "LIKE", likeEscaper.Replace(value) + "%"
+	}
+	return "=", value
+}
+
+// Transactions returns a page of transactions matching the filter in newest first order.
+func (s *Store) Transactions(f model.TxFilter, p model.Page) (model.TxPage, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("transactions")).ObserveDuration()
+	return s.transactions(f, p)
+}
+
+func (s *Store) transactions(f model.TxFilter, p model.Page) (model.TxPage, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Transactions of other kinds are listed only when asked by kind or
+	// searched by text.
+	txWhere := []string{"TRUE"}
+	switch {
+	case f.Kind != "":
+		txWhere = append(txWhere, "t.kind::text = "+arg(string(f.Kind)))
+	case f.Text == "":
+		txWhere = append(txWhere, "t.kind IN ('run', 'proof', 'verification')")
+	}
+	if f.Author != "" {
+		op, author := hashMatch(f.Author, f.AuthorPrefix)
+		txWhere = append(txWhere, "t.author "+op+" "+arg(author))
+	}
+	if !f.Since.IsZero() {
+		txWhere = append(txWhere, "t.created_at >= "+arg(f.Since))
+	}
+	if !f.Until.IsZero() {
+		txWhere = append(txWhere, "t.created_at < "+arg(f.Until))
+	}
+
+	order := "DESC"
+	switch {
+	case !p.After.IsZero():
+		txWhere = append(txWhere, fmt.Sprintf("(t.created_at, t.hash) > (%s, %s)", arg(p.After.Timestamp), arg(p.After.TxID)))
+		order = "ASC"
+	case !p.Before.IsZero():
+		txWhere = append(txWhere, fmt.Sprintf("(t.created_at, t.hash) < (%s, %s)", arg(p.Before.Timestamp), arg(p.Before.TxID)))
+	}
+
+	// Free text is matched in separate branches below the filter of the
+	// transactions, so each branch can use the hash prefix and program name
+	// indexes. Same semantics as model.TxFilter.Match, names are similar when
+	// the % operator of pg_trgm holds.
+	with := ""
+	if f.Text != "" {
+		op, hash := hashMatch(f.Text, f.TextPrefix)
+		hashArg, nameArg, textArg := arg(hash), arg("%"+likeEscaper.Replace(f.Text)+"%"), arg(f.Text)
+		with = fmt.Sprintf(`
+			matched_runs AS (
+				SELECT ws.tx FROM workflow_step AS ws
+				WHERE ws.sequence = 1 AND (ws.program %[1]s %[2]s OR ws.program IN (SELECT hash FROM program WHERE name ILIKE %[3]s OR name %% %[4]s))
+			), matched_txs AS (
+				SELECT hash FROM transaction WHERE hash %[1]s %[2]s
+				UNION SELECT hash FROM transaction WHERE author %[1]s %[2]s
+				UNION SELECT tx FROM matched_runs
+				UNION SELECT tx FROM proof WHERE parent IN (SELECT tx FROM matched_runs)
+				UNION SELECT v.tx FROM verification AS v JOIN proof AS p ON p.tx = v.parent WHERE p.parent IN (SELECT tx FROM matched_runs)
+				UNION SELECT tx FROM proof_key WHERE parent IN (SELECT tx FROM matched_runs)
+				UNION SELECT k.tx FROM proof_key AS k JOIN proof AS p ON p.tx = k.parent WHERE p.parent IN (SELECT tx FROM matched_runs)
+			),`, op, hashArg, nameArg, textArg)
+		txWhere = append(txWhere, "t.hash IN (SELECT hash FROM matched_txs)")
+	}
+
+	// Run, state, prover and tag are filtered next to the transaction filter,
+	// so that the newest matching transactions are found in index order.
+	if f.State != model.StateUnknown {
+		txWhere = append(txWhere, "st.state = "+arg(f.State.String()))
+	}
+	if f.Prover != "" {
+		op, prover := hashMatch(f.Prover, f.ProverPrefix)
+		txWhere = append(txWhere, "ws.program "+op+" "+arg(prover))
+	}
+	if f.Tag != "" {
+		txWhere = append(txWhere, "lower(pr.name) = lower("+arg(f.Tag)+")")
+	}
+
+	// Every transaction is shown with the state of the run it belongs to.
+	query := fmt.Sprintf(`
+		WITH %s txs AS (
+			SELECT
+				st.state,
+				t.hash AS tx_id,
+				t.kind::text AS kind,
+				t.author,
+				COALESCE(ws.program, '') AS prover_id,
+				COALESCE(pr.name, '') AS tag,
+				t.created_at AS timestamp
+			FROM transaction AS t
+			CROSS JOIN LATERAL (
+				SELECT CASE t.kind
+					WHEN 'run' THEN t.hash
+					WHEN 'proof' THEN (SELECT parent FROM proof WHERE tx = t.hash)
+					WHEN 'proofkey' THEN (SELECT COALESCE((SELECT p.parent FROM proof AS p WHERE p.tx = k.parent), k.parent) FROM proof_key AS k WHERE k.tx = t.hash)
+					WHEN 'verification' THEN (SELECT p.parent FROM proof AS p JOIN verification AS v ON p.tx = v.parent WHERE v.tx = t.hash)
+				END AS run
+			) AS r
+			CROSS JOIN LATERAL (
+				SELECT
+					-- Transactions outside of runs are complete once stored.
+					CASE WHEN r.run IS NULL THEN 'complete'
+						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = r.run) > 2 THEN 'complete'
+						WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = r.run) >= 1 THEN 'verifying'
+						WHEN (SELECT COUNT(*) FROM proof WHERE parent = r.run) >= 1 THEN 'proving'
+						ELSE 'submitted'
+					END AS state
+			) AS st
+			LEFT JOIN workflow_step AS ws ON ws.tx = r.run AND ws.sequence = 1
+			LEFT JOIN program AS pr ON pr.hash = ws.program
+			WHERE %s
+		)
+		SELECT * FROM txs
+		ORDER BY timestamp %s, tx_id %s
+		LIMIT %s`,
+		with, strings.Join(txWhere, " AND "), order, order, arg(p.Limit+1))
+
+	var events []model.Event
+	if _, err := s.db.Select(&events, query, args...); err != nil {
+		return model.TxPage{}, err
+	}
+
+	return model.NewTxPage(events, p), nil
 }
 
 func (s *Store) TxInfo(id string) (model.TxInfo, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("tx_info")).ObserveDuration()
 	var tx gevulotTransaction
 	const fetchTxQuery = `SELECT * FROM transaction WHERE hash = $1`
-	if err := s.db.SelectOne(&tx, fetchTxQuery, id); err != nil {
+	err := s.db.SelectOne(&tx, fetchTxQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.TxInfo{}, fmt.Errorf("tx %s: %w", id, model.ErrNotFound)
+	}
+
+	if err != nil {
 		slog.Error("failed to find transaction", slog.Any("err", err))
 		return model.TxInfo{}, err
 	}
@@ -268,9 +604,9 @@ func (s *Store) TxInfo(id string) (model.TxInfo, error) {
 	`
 	var txHash string
 	switch tx.Kind {
-	case run:
+	case model.KindRun:
 		txHash = tx.Hash
-	case proof:
+	case model.KindProof:
 		{
 			const fetchProofParent = `SELECT parent FROM proof WHERE tx = $1`
 			if err := s.db.SelectOne(&txHash, fetchProofParent, tx.Hash); err != nil {
@@ -278,7 +614,16 @@ func (s *Store) TxInfo(id string) (model.TxInfo, error) {
 				return model.TxInfo{}, err
 			}
 		}
-	case verification:
+	case model.KindProofKey:
+		{
+			// Proof key refers either to the run or to its proof.
+			const fetchProofKeyRun = `SELECT COALESCE((SELECT p.parent FROM proof AS p WHERE p.tx = k.parent), k.parent) FROM proof_key AS k WHERE k.tx = $1`
+			if err := s.db.SelectOne(&txHash, fetchProofKeyRun, tx.Hash); err != nil {
+				slog.Error("failed to find parent transaction for proof key", slog.Any("err", err))
+				return model.TxInfo{}, err
+			}
+		}
+	case model.KindVerification:
 		{
 			const fetchVerificationParent = `SELECT p.parent FROM proof AS p JOIN verification AS v ON p.tx = v.parent WHERE v.tx = $1`
 			if err := s.db.SelectOne(&txHash, fetchVerificationParent, tx.Hash); err != nil {
@@ -287,8 +632,7 @@ func (s *Store) TxInfo(id string) (model.TxInfo, error) {
 			}
 		}
 	default:
-		slog.Error("invalid transaction kind", slog.Any("tx.Kind", tx.Kind))
-		return model.TxInfo{}, fmt.Errorf("invalid transaction kind: %q", tx.Kind)
+		return s.standaloneTxInfo(tx)
 	}
 
 	var txs []gevulotTransaction
@@ -297,7 +641,7 @@ func (s *Store) TxInfo(id string) (model.TxInfo, error) {
 		return model.TxInfo{}, err
 	}
 
-	findProverProgramHashQuery := `SELECT ws.program FROM workflow_step AS ws WHERE ws.tx = $1`
+	findProverProgramHashQuery := `SELECT ws.program FROM workflow_step AS ws WHERE ws.tx = $1 AND ws.sequence = 1`
 	var proverHash string
 	if err := s.db.SelectOne(&proverHash, findProverProgramHashQuery, txHash); err != nil {
 		slog.Error("failed to query prover program hash", slog.Any("run_tx_hash", txHash))
@@ -306,58 +650,615 @@ func (s *Store) TxInfo(id string) (model.TxInfo, error) {
 
 	var author string
 	for _, tx := range txs {
-		if tx.Kind == "run" {
+		if tx.Kind == model.KindRun {
 			author = tx.Author
 			break
 		}
 	}
 
+	workflow, err := s.workflow(txHash)
+	if err != nil {
+		slog.Error("failed to query workflow", slog.Any("run_tx_hash", txHash), slog.Any("err", err))
+		return model.TxInfo{}, err
+	}
+
+	hashes := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+
+	var files []model.TxFile
+	const fetchTxFilesQuery = `SELECT tx_id, name, url, checksum FROM txfile WHERE tx_id = ANY($1) ORDER BY tx_id, name`
+	if _, err := s.db.Select(&files, fetchTxFilesQuery, hashes); err != nil {
+		slog.Error("failed to query tx files", slog.Any("run_tx_hash", txHash), slog.Any("err", err))
+		return model.TxInfo{}, err
+	}
+
+	// Proof keys refer either to the run or to its proof.
+	const fetchPayloadsQuery = `
+		SELECT tx AS tx_id, 'proof' AS kind, octet_length(proof) AS size, encode(sha256(proof), 'hex') AS sha256
+		FROM proof WHERE parent = $1
+		UNION ALL
+		SELECT v.tx, 'verification', octet_length(v.verification), encode(sha256(v.verification), 'hex')
+		FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = $1
+		UNION ALL
+		SELECT k.tx, 'proof_key', octet_length(k.key), encode(sha256(k.key), 'hex')
+		FROM proof_key AS k WHERE k.parent = $1 OR k.parent IN (SELECT tx FROM proof WHERE parent = $1)`
+
+	var payloads []model.Payload
+	if _, err := s.db.Select(&payloads, fetchPayloadsQuery, txHash); err != nil {
+		slog.Error("failed to query payloads", slog.Any("run_tx_hash", txHash), slog.Any("err", err))
+		return model.TxInfo{}, err
+	}
+
 	info := model.TxInfo{
 		State:    getState(txs),
+		Kind:     tx.Kind,
 		Duration: getJobDuration(txs),
 		TxID:     txHash,
 		UserID:   author,
 		ProverID: proverHash,
 		Log:      txLogEventsFromTxs(txs),
+		Workflow: workflow,
+		Files:    files,
+		Payloads: payloads,
 	}
 
 	return info, nil
 }
 
-func (s *Store) LatestDailyStats() (model.Stats, error) {
-	const statsQuery = `
-		SELECT * FROM daily_stats
-		ORDER BY created_at DESC
-		LIMIT 1;`
+// standaloneTxInfo returns info of a transaction that doesn't belong to a run.
+// Such transactions take effect once they are stored, so they are complete.
+func (s *Store) standaloneTxInfo(tx gevulotTransaction) (model.TxInfo, error) {
+	info := model.TxInfo{
+		State:  model.StateComplete,
+		Kind:   tx.Kind,
+		TxID:   tx.Hash,
+		UserID: tx.Author,
+		Log: []model.TxLogEvent{{
+			State:     model.StateComplete,
+			IDType:    "user id",
+			ID:        tx.Author,
+			Timestamp: tx.Created_at,
+		}},
+	}
 
-	var stats model.Stats
-	err := s.db.SelectOne(&stats, statsQuery)
+	if tx.Kind != model.KindDeploy {
+		return info, nil
+	}
+
+	var d model.Deployment
+	const fetchDeploymentQuery = `SELECT COALESCE(name, '') AS name, COALESCE(prover, '') AS prover, COALESCE(verifier, '') AS verifier FROM deploy WHERE tx = $1`
+	err := s.db.SelectOne(&d, fetchDeploymentQuery, tx.Hash)
 	if errors.Is(err, sql.ErrNoRows) {
-		return model.Stats{}, model.ErrNotFound
+		return info, nil
 	}
 
 	if err != nil {
-		return model.Stats{}, err
+		slog.Error("failed to query deployment", slog.Any("tx_hash", tx.Hash), slog.Any("err", err))
+		return model.TxInfo{}, err
 	}
 
-	return stats, nil
+	info.ProverID = d.Prover
+	info.Deployment = &d
+	return info, nil
+}
+
+// payload is a transaction payload with its data as queried from the database.
+type payload struct {
+	model.Payload
+	Data []byte
+}
+
+// Payload returns payload of proof, verification or proof key transaction with
+// length bytes of its data starting from offset. If length is 0, data is read
+// until the end.
+func (s *Store) Payload(tx string, offset, length int) (model.Payload, []byte, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("payload")).ObserveDuration()
+
+	// substring positions start from 1.
+	data := "substring(%[1]s FROM $2)"
+	args := []any{tx, offset + 1}
+	if length > 0 {
+		data = "substring(%[1]s FROM $2 FOR $3)"
+		args = append(args, length)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT tx AS tx_id, 'proof' AS kind, octet_length(proof) AS size, encode(sha256(proof), 'hex') AS sha256, %[1]s AS data
+		FROM proof WHERE tx = $1
+		UNION ALL
+		SELECT tx, 'verification', octet_length(verification), encode(sha256(verification), 'hex'), %[2]s
+		FROM verification WHERE tx = $1
+		UNION ALL
+		SELECT tx, 'proof_key', octet_length(key), encode(sha256(key), 'hex'), %[3]s
+		FROM proof_key WHERE tx = $1`,
+		fmt.Sprintf(data, "proof"), fmt.Sprintf(data, "verification"), fmt.Sprintf(data, "key"))
+
+	var p payload
+	err := s.db.SelectOne(&p, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Payload{}, nil, fmt.Errorf("payload of tx %s: %w", tx, model.ErrNotFound)
+	}
+
+	if err != nil {
+		return model.Payload{}, nil, fmt.Errorf("failed to query payload: %w", err)
+	}
+	return p.Payload, p.Data, nil
 }
 
-func (s *Store) AggregateStats(t time.Time) error {
-	stats, err := s.CurrentStats()
+// workflowStep is a workflow step as queried from the database. Args are
+// queried as JSON array.
+type workflowStep struct {
+	ID          int64
+	Sequence    int
+	Program     string
+	ProgramName string `db:"program_name"`
+	Args        string
+}
+
+// stepFile is a program input or output file of a workflow step.
+type stepFile struct {
+	StepID        int64 `db:"workflow_step_id"`
+	Name          string
+	URL           string
+	Checksum      string
+	SourceProgram string `db:"source_program"`
+}
+
+// workflow returns workflow steps of the run in order with their input and output files.
+func (s *Store) workflow(runHash string) ([]model.WorkflowStep, error) {
+	const stepsQuery = `
+		SELECT
+			ws.id,
+			ws.sequence,
+			ws.program,
+			COALESCE(p.name, '') AS program_name,
+			COALESCE(array_to_json(ws.args)::text, '[]') AS args
+		FROM workflow_step AS ws
+		LEFT JOIN program AS p ON p.hash = ws.program
+		WHERE ws.tx = $1
+		ORDER BY ws.sequence`
+
+	var rows []workflowStep
+	if _, err := s.db.Select(&rows, stepsQuery, runHash); err != nil {
+		return nil, fmt.Errorf("failed to query workflow steps: %w", err)
+	}
+
+	const inputsQuery = `
+		SELECT i.workflow_step_id, i.file_name AS name, i.file_url AS url, i.checksum
+		FROM program_input_data AS i
+		JOIN workflow_step AS ws ON ws.id = i.workflow_step_id
+		WHERE ws.tx = $1
+		ORDER BY i.file_name`
+
+	var inputs []stepFile
+	if _, err := s.db.Select(&inputs, inputsQuery, runHash); err != nil {
+		return nil, fmt.Errorf("failed to query workflow inputs: %w", err)
+	}
+
+	const outputsQuery = `
+		SELECT o.workflow_step_id, o.file_name AS name, o.source_program
+		FROM program_output_data AS o
+		JOIN workflow_step AS ws ON ws.id = o.workflow_step_id
+		WHERE ws.tx = $1
+		ORDER BY o.file_name`
+
+	var outputs []stepFile
+	if _, err := s.db.Select(&outputs, outputsQuery, runHash); err != nil {
+		return nil, fmt.Errorf("failed to query workflow outputs: %w", err)
+	}
+
+	steps := make([]model.WorkflowStep, 0, len(rows))
+	for _, row := range rows {
+		step := model.WorkflowStep{
+			Sequence:    row.Sequence,
+			Program:     row.Program,
+			ProgramName: row.ProgramName,
+		}
+		if err := json.Unmarshal([]byte(row.Args), &step.Args); err != nil {
+			return nil, fmt.Errorf("invalid args of workflow step %d: %w", row.ID, err)
+		}
+
+		for _, f := range inputs {
+			if f.StepID == row.ID {
+				step.Inputs = append(step.Inputs, model.InputFile{Name: f.Name, URL: f.URL, Checksum: f.Checksum})
+			}
+		}
+		for _, f := range outputs {
+			if f.StepID == row.ID {
+				step.Outputs = append(step.Outputs, model.OutputFile{Name: f.Name, SourceProgram: f.SourceProgram})
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// programStats holds run statistics of a program as queried from the database.
+type programStats struct {
+	Runs       uint64
+	Completed  uint64
+	AvgLatency float64 `db:"avg_latency"`
+}
+
+// Program returns the program with statistics and 10 most recent runs using it
+// in any of their workflow steps.
+func (s *Store) Program(hash string) (model.ProgramInfo, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("program")).ObserveDuration()
+
+	const programQuery = `
+		SELECT
+			p.hash,
+			p.name,
+			p.image_file_name,
+			p.image_file_url,
+			p.image_file_checksum,
+			COALESCE(r.memory, 0) AS memory,
+			COALESCE(r.cpus, 0) AS cpus,
+			COALESCE(r.gpus, 0) AS gpus,
+			COALESCE((SELECT d.tx FROM deploy AS d WHERE d.prover = p.hash OR d.verifier = p.hash LIMIT 1), '') AS deploy_tx,
+			EXISTS (SELECT 1 FROM deploy AS d WHERE d.prover = p.hash) AS prover,
+			EXISTS (SELECT 1 FROM deploy AS d WHERE d.verifier = p.hash) AS verifier
+		FROM program AS p
+		LEFT JOIN program_resource_requirements AS r ON r.program_hash = p.hash
+		WHERE p.hash = $1`
+
+	var p model.ProgramInfo
+	err := s.db.SelectOne(&p.Program, programQuery, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ProgramInfo{}, fmt.Errorf("program %s: %w", hash, model.ErrNotFound)
+	}
+
+	if err != nil {
+		return model.ProgramInfo{}, fmt.Errorf("failed to query program: %w", err)
+	}
+
+	// Run is complete after its third verification, same as in TxInfo.
+	const statsQuery = `
+		WITH runs AS (
+			SELECT
+				t.hash,
+				t.created_at,
+				COUNT(v.tx) AS verifications,
+				MAX(vt.created_at) AS verified_at
+			FROM transaction AS t
+			LEFT JOIN proof AS p ON p.parent = t.hash
+			LEFT JOIN verification AS v ON v.parent = p.tx
+			LEFT JOIN transaction AS vt ON vt.hash = v.tx
+			WHERE t.hash IN (SELECT tx FROM workflow_step WHERE program = $1)
+			GROUP BY t.hash, t.created_at
+		)
+		SELECT
+			COUNT(*) AS runs,
+			COUNT(*) FILTER (WHERE verifications > 2) AS completed,
+			COALESCE(EXTRACT(EPOCH FROM AVG(verified_at - created_at) FILTER (WHERE verifications > 2)), 0) AS avg_latency
+		FROM runs`
+
+	var stats programStats
+	if err := s.db.SelectOne(&stats, statsQuery, hash); err != nil {
+		return model.ProgramInfo{}, fmt.Errorf("failed to query program stats: %w", err)
+	}
+
+	p.Stats = model.ProgramStats{
+		Runs:       stats.Runs,
+		Completed:  stats.Completed,
+		AvgLatency: time.Duration(stats.AvgLatency * float64(time.Second)),
+	}
+	if stats.Runs > 0 {
+		p.Stats.SuccessRate = float64(stats.Completed) / float64(stats.Runs) * 100
+	}
+
+	const runsQuery = `
+		SELECT
+			CASE WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = t.hash) > 2 THEN 'complete'
+				WHEN (SELECT COUNT(*) FROM verification AS v JOIN proof AS p ON v.parent = p.tx WHERE p.parent = t.hash) >= 1 THEN 'verifying'
+				WHEN (SELECT COUNT(*) FROM proof WHERE parent = t.hash) >= 1 THEN 'proving'
+				ELSE 'submitted'
+			END AS state,
+			t.hash AS tx_id,
+			t.kind::text AS kind,
+			t.author,
+			COALESCE(ws.program, '') AS prover_id,
+			COALESCE(pr.name, '') AS tag,
+			t.created_at AS timestamp
+		FROM transaction AS t
+		LEFT JOIN workflow_step AS ws ON ws.tx = t.hash AND ws.sequence = 1
+		LEFT JOIN program AS pr ON pr.hash = ws.program
+		WHERE t.hash IN (SELECT tx FROM workflow_step WHERE program = $1)
+		ORDER BY t.created_at DESC, t.hash DESC
+		LIMIT 10`
+
+	if _, err := s.db.Select(&p.RecentRuns, runsQuery, hash); err != nil {
+		return model.ProgramInfo{}, fmt.Errorf("failed to query program runs: %w", err)
+	}
+	return p, nil
+}
+
+// accountKind holds number of transactions of one kind authored by an account.
+type accountKind struct {
+	Kind      string
+	Count     uint64
+	FirstSeen time.Time `db:"first_seen"`
+	LastSeen  time.Time `db:"last_seen"`
+}
+
+// Account returns the account with its transaction counts and 10 most recent
+// transactions.
+func (s *Store) Account(key string) (model.AccountInfo, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("account")).ObserveDuration()
+
+	const kindsQuery = `
+		SELECT kind::text AS kind, COUNT(*) AS count, MIN(created_at) AS first_seen, MAX(created_at) AS last_seen
+		FROM transaction
+		WHERE author = $1
+		GROUP BY kind`
+
+	var kinds []accountKind
+	if _, err := s.db.Select(&kinds, kindsQuery, key); err != nil {
+		return model.AccountInfo{}, fmt.Errorf("failed to query account transactions: %w", err)
+	}
+
+	whitelisted, err := s.db.SelectInt(`SELECT COUNT(*) FROM acl_whitelist WHERE key = $1`, key)
+	if err != nil {
+		return model.AccountInfo{}, fmt.Errorf("failed to query acl whitelist: %w", err)
+	}
+
+	if len(kinds) == 0 && whitelisted == 0 {
+		return model.AccountInfo{}, fmt.Errorf("account %s: %w", key, model.ErrNotFound)
+	}
+
+	acc := model.AccountInfo{Account: model.Account{
+		Key:          key,
+		Whitelisted:  whitelisted > 0,
+		Transactions: make(map[string]uint64, len(kinds)),
+	}}
+	for _, k := range kinds {
+		acc.Transactions[k.Kind] = k.Count
+		if acc.FirstSeen.IsZero() || k.FirstSeen.Before(acc.FirstSeen) {
+			acc.FirstSeen = k.FirstSeen
+		}
+		if k.LastSeen.After(acc.LastSeen) {
+			acc.LastSeen = k.LastSeen
+		}
+	}
+
+	page, err := s.transactions(model.TxFilter{Author: key}, model.Page{Limit: 10})
 	if err != nil {
-		return fmt.Errorf("failed to get current stats: %w", err)
+		return model.AccountInfo{}, fmt.Errorf("failed to query account recent transactions: %w", err)
 	}
+	acc.RecentTransactions = page.Events
+	return acc, nil
+}
+
+// leaderboardSize is the number of nodes listed in the leaderboard.
+const leaderboardSize = 100
+
+// nodeRank is a leaderboard entry as queried from the database.
+type nodeRank struct {
+	Node          string
+	Proofs        uint64
+	Verifications uint64
+	MedianLatency float64 `db:"median_latency"`
+	Share         float64
+}
+
+// Leaderboard returns nodes ranked by number of proofs and verifications they
+// have produced within the range.
+func (s *Store) Leaderboard(r model.StatsRange) ([]model.NodeRank, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("leaderboard")).ObserveDuration()
 
+	const query = `
+		WITH work AS (
+			SELECT t.author, t.kind, t.created_at - run.created_at AS latency
+			FROM transaction AS t
+			JOIN proof AS p ON p.tx = t.hash
+			JOIN transaction AS run ON run.hash = p.parent
+			WHERE t.kind = 'proof' AND t.created_at > $1 AND ($3::timestamptz IS NULL OR t.created_at < $3)
+			UNION ALL
+			SELECT t.author, t.kind, t.created_at - run.created_at AS latency
+			FROM transaction AS t
+			JOIN verification AS v ON v.tx = t.hash
+			JOIN proof AS p ON p.tx = v.parent
+			JOIN transaction AS run ON run.hash = p.parent
+			WHERE t.kind = 'verification' AND t.created_at > $1 AND ($3::timestamptz IS NULL OR t.created_at < $3)
+		)
+		SELECT
+			author AS node,
+			COUNT(*) FILTER (WHERE kind = 'proof') AS proofs,
+			COUNT(*) FILTER (WHERE kind = 'verification') AS verifications,
+			EXTRACT(EPOCH FROM percentile_cont(0.5) WITHIN GROUP (ORDER BY latency)) AS median_latency,
+			COUNT(*) * 100.0 / SUM(COUNT(*)) OVER () AS share
+		FROM work
+		GROUP BY author
+		ORDER BY COUNT(*) DESC, author
+		LIMIT $2`
+
+	var rows []nodeRank
+	if _, err := s.db.Select(&rows, query, r.Since(), leaderboardSize, until(r)); err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+
+	nodes := make([]model.NodeRank, 0, len(rows))
+	for _, row := range rows {
+		nodes = append(nodes, model.NodeRank{
+			Node:          row.Node,
+			Proofs:        row.Proofs,
+			Verifications: row.Verifications,
+			MedianLatency: time.Duration(row.MedianLatency * float64(time.Second)),
+			Share:         row.Share,
+		})
+	}
+	return nodes, nil
+}
+
+// MissingDailyStats returns UTC days from the first day with stats or
+// transactions up to the day of until which have no stats, oldest first. Days
+// since the first transaction are missing until stats have been aggregated.
+func (s *Store) MissingDailyStats(until time.Time) ([]time.Time, error) {
+	// Days are passed as dates, so that the session time zone doesn't affect
+	// them.
+	const query = `
+		SELECT d::date AS day
+		FROM generate_series(
+			COALESCE(LEAST(
+				(SELECT MIN((created_at AT TIME ZONE 'UTC')::date) FROM daily_stats),
+				(SELECT MIN((created_at AT TIME ZONE 'UTC')::date) FROM transaction)
+			), $1::date),
+			$1::date,
+			interval '1 day'
+		) AS d
+		WHERE NOT EXISTS (
+			SELECT 1 FROM daily_stats WHERE (created_at AT TIME ZONE 'UTC')::date = d::date
+		)
+		ORDER BY day`
+
+	var days []time.Time
+	if _, err := s.db.Select(&days, query, until.UTC().Format(time.DateOnly)); err != nil {
+		return nil, fmt.Errorf("failed to query days without stats: %w", err)
+	}
+	return days, nil
+}
+
+// AggregateStats stores totals at the end of the given UTC day, reconstructed
+// from creation times of transactions, with the start of the day as their
+// creation time. Earlier stats of the day are replaced.
+//
+// Whitelist has no timestamps, so whitelisted keys are counted as registered
+// from their first transaction on, or from the beginning if they have none.
+func (s *Store) AggregateStats(day time.Time) error {
+	day = day.UTC().Truncate(24 * time.Hour)
+	end := day.AddDate(0, 0, 1)
+
+	// Conflict target matches daily_stats_day_idx, so concurrent or repeated
+	// aggregations of the same day are idempotent.
 	const query = `
 		INSERT INTO
 			daily_stats (created_at, registered_users, proofs_generated, programs, proofs_verified)
-		VALUES
-			($1, $2, $3, $4, $5);`
+		SELECT
+			$1,
+			(SELECT COUNT(*) FROM acl_whitelist AS w
+				WHERE COALESCE((SELECT MIN(t.created_at) FROM transaction AS t WHERE t.author = w.key), '-infinity') < $2),
+			(SELECT COUNT(*) FROM transaction WHERE kind = 'proof' AND created_at < $2),
+			(SELECT COUNT(DISTINCT d.prover) FROM deploy AS d JOIN transaction AS t ON t.hash = d.tx WHERE t.created_at < $2),
+			(SELECT COUNT(*) FROM transaction WHERE kind = 'verification' AND created_at < $2)
+		ON CONFLICT (((created_at AT TIME ZONE 'UTC')::date)) DO UPDATE SET
+			created_at = EXCLUDED.created_at,
+			registered_users = EXCLUDED.registered_users,
+			proofs_generated = EXCLUDED.proofs_generated,
+			programs = EXCLUDED.programs,
+			proofs_verified = EXCLUDED.proofs_verified`
+	if _, err := s.db.Exec(query, day, end); err != nil {
+		return fmt.Errorf("failed to upsert daily stats: %w", err)
+	}
+	return nil
+}
+
+// hourlyStats is hourly stats as stored in the database, with latencies in seconds.
+type hourlyStats struct {
+	Hour          time.Time
+	Runs          uint64
+	Proofs        uint64
+	Verifications uint64
+	LatencyP50    float64 `db:"latency_p50"`
+	LatencyP95    float64 `db:"latency_p95"`
+}
+
+func (h hourlyStats) toModel() model.HourlyStats {
+	return model.HourlyStats{
+		Hour:          h.Hour,
+		Runs:          h.Runs,
+		Proofs:        h.Proofs,
+		Verifications: h.Verifications,
+		LatencyP50:    time.Duration(h.LatencyP50 * float64(time.Second)),
+		LatencyP95:    time.Duration(h.LatencyP95 * float64(time.Second)),
+	}
+}
+
+// HourlyStats returns hourly stats of hours starting within [since, until) in
+// oldest first order.
+func (s *Store) HourlyStats(since, until time.Time) ([]model.HourlyStats, error) {
+	defer prometheus.NewTimer(metrics.DBQueryDuration.WithLabelValues("hourly_stats")).ObserveDuration()
+	const query = `SELECT * FROM hourly_stats WHERE hour >= $1 AND hour < $2 ORDER BY hour ASC`
+
+	var rows []hourlyStats
+	if _, err := s.db.Select(&rows, query, since, until); err != nil {
+		return nil, fmt.Errorf("failed to query hourly stats: %w", err)
+	}
+
+	stats := make([]model.HourlyStats, 0, len(rows))
+	for _, h := range rows {
+		stats = append(stats, h.toModel())
+	}
+	return stats, nil
+}
+
+func (s *Store) LatestHourlyStats() (model.HourlyStats, error) {
+	const query = `SELECT * FROM hourly_stats ORDER BY hour DESC LIMIT 1`
+
+	var h hourlyStats
+	err := s.db.SelectOne(&h, query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.HourlyStats{}, model.ErrNotFound
+	}
 
-	_, err = s.db.Exec(query, t, stats.RegisteredUsers, stats.ProofsGenerated, stats.ProversDeployed, stats.ProofsVerified)
 	if err != nil {
-		return fmt.Errorf("failed to insert daily stats: %w", err)
+		return model.HourlyStats{}, err
+	}
+	return h.toModel(), nil
+}
+
+// AggregateHourlyStats computes stats of the hour starting at given time and
+// stores them, replacing earlier stats of the same hour.
+func (s *Store) AggregateHourlyStats(hour time.Time) error {
+	// Run is complete after its third verification, same as in TxInfo.
+	const query = `
+		WITH verified AS (
+			SELECT
+				p.parent AS run,
+				t.created_at,
+				row_number() OVER (PARTITION BY p.parent ORDER BY t.created_at) AS n
+			FROM verification AS v
+			JOIN proof AS p ON p.tx = v.parent
+			JOIN transaction AS t ON t.hash = v.tx
+			WHERE p.parent IN (
+				SELECT p.parent FROM verification AS v
+				JOIN proof AS p ON p.tx = v.parent
+				JOIN transaction AS t ON t.hash = v.tx
+				WHERE t.created_at >= $1 AND t.created_at < $2
+			)
+		), completed AS (
+			SELECT EXTRACT(EPOCH FROM v.created_at - r.created_at) AS latency
+			FROM verified AS v
+			JOIN transaction AS r ON r.hash = v.run
+			WHERE v.n = 3 AND v.created_at >= $1 AND v.created_at < $2
+		)
+		INSERT INTO hourly_stats (hour, runs, proofs, verifications, latency_p50, latency_p95)
+		SELECT
+			$1,
+			(SELECT COUNT(*) FROM transaction WHERE kind = 'run' AND created_at >= $1 AND created_at < $2),
+			(SELECT COUNT(*) FROM transaction WHERE kind = 'proof' AND created_at >= $1 AND created_at < $2),
+			(SELECT COUNT(*) FROM transaction WHERE kind = 'verification' AND created_at >= $1 AND created_at < $2),
+			COALESCE((SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY latency) FROM completed), 0),
+			COALESCE((SELECT percentile_cont(0.95) WITHIN GROUP (ORDER BY latency) FROM completed), 0)
+		ON CONFLICT (hour) DO UPDATE SET
+			runs = EXCLUDED.runs,
+			proofs = EXCLUDED.proofs,
+			verifications = EXCLUDED.verifications,
+			latency_p50 = EXCLUDED.latency_p50,
+			latency_p95 = EXCLUDED.latency_p95`
+
+	hour = hour.Truncate(time.Hour)
+	if _, err := s.db.Exec(query, hour, hour.Add(time.Hour)); err != nil {
+		return fmt.Errorf("failed to aggregate hourly stats: %w", err)
+	}
+	return nil
+}
+
+// DeleteHourlyStats deletes hourly stats of hours before given time.
+func (s *Store) DeleteHourlyStats(before time.Time) error {
+	if _, err := s.db.Exec(`DELETE FROM hourly_stats WHERE hour < $1`, before); err != nil {
+		return fmt.Errorf("failed to delete hourly stats: %w", err)
 	}
 	return nil
 }
@@ -366,7 +1267,82 @@ func (s *Store) Events() <-chan model.Event {
 	return s.events
 }
 
+// Ready returns an error if store is not listening for notifications or
+// database can't be reached.
+func (s *Store) Ready() error {
+	if !s.listening.Load() {
+		return errors.New("not listening for notifications")
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, time.Second)
+	defer cancel()
+	if err := s.db.Db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// IsLeader reports whether this replica holds the aggregator lock, trying to
+// acquire it if not. Lock is a session level advisory lock, which is held on
+// a dedicated connection until the store is stopped or the connection is lost.
+func (s *Store) IsLeader() (bool, error) {
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	if s.lockConn != nil {
+		err := s.lockConn.PingContext(ctx)
+		if err == nil {
+			return true, nil
+		}
+
+		// Lock is released with the lost session.
+		slog.Error("lost aggregator lock connection", slog.Any("err", err))
+		s.lockConn.Close()
+		s.lockConn = nil
+	}
+
+	conn, err := s.db.Db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, aggregatorLockID).Scan(&locked); err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to acquire aggregator lock: %w", err)
+	}
+
+	if !locked {
+		conn.Close()
+		return false, nil
+	}
+
+	s.lockConn = conn
+	return true, nil
+}
+
+// releaseLock releases the aggregator lock if it's held.
+func (s *Store) releaseLock() {
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+	if s.lockConn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := s.lockConn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, aggregatorLockID); err != nil {
+		slog.Error("failed to release aggregator lock", slog.Any("err", err))
+	}
+	s.lockConn.Close()
+	s.lockConn = nil
+}
+
 func (s *Store) Stop() error {
+	s.releaseLock()
 	s.cancel()
 	s.db.Db.Close()
 	return nil
@@ -394,9 +1370,9 @@ func getState(txs []gevulotTransaction) model.State {
 	verifications := 0
 
 	for _, tx := range txs {
-		if tx.Kind == "proof" {
+		if tx.Kind == model.KindProof {
 			proofs++
-		} else if tx.Kind == "verification" {
+		} else if tx.Kind == model.KindVerification {
 			verifications++
 		}
 	}
@@ -413,13 +1389,13 @@ func getState(txs []gevulotTransaction) model.State {
 	}
 }
 
-func stateFromKind(k txKind) model.State {
+func stateFromKind(k model.TxKind) model.State {
 	switch k {
-	case run:
+	case model.KindRun:
 		return model.StateSubmitted
-	case proof:
+	case model.KindProof:
 		return model.StateProving
-	case verification:
+	case model.KindVerification:
 		return model.StateVerifying
 	default:
 		return model.StateUnknown